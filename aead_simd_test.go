@@ -0,0 +1,59 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"crypto/cipher"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestAEADConformanceSIMD(t *testing.T) {
+	t.Run("AEAD128L", func(t *testing.T) {
+		testAEAD(t, func(key []byte) cipher.AEAD { return newAEAD128L(t, [16]byte(key)) }, 16)
+	})
+	t.Run("AEAD128x4", func(t *testing.T) {
+		testAEAD(t, func(key []byte) cipher.AEAD { return newAEAD128x4(t, [16]byte(key)) }, 16)
+	})
+	t.Run("AEAD256", func(t *testing.T) {
+		testAEAD(t, func(key []byte) cipher.AEAD { return newAEAD256(t, [32]byte(key)) }, 32)
+	})
+	t.Run("AEAD256x2", func(t *testing.T) {
+		testAEAD(t, func(key []byte) cipher.AEAD { return newAEAD256x2(t, [32]byte(key)) }, 32)
+	})
+	t.Run("AEAD256x4", func(t *testing.T) {
+		testAEAD(t, func(key []byte) cipher.AEAD { return newAEAD256x4(t, [32]byte(key)) }, 32)
+	})
+}
+
+// TestZeroValue4Lane checks that the 4-lane variants refuse to run unless
+// their constructors, which check the CPU, created them.
+func TestZeroValue4Lane(t *testing.T) {
+	nonce16, nonce32 := make([]byte, 16), make([]byte, 32)
+
+	var a128 aegis.AEAD128x4
+	mustPanic(t, "AEAD128x4{}.Seal", func() { a128.Seal(nil, nonce16, nil, nil) })
+	if _, err := a128.TrySeal(nil, nonce16, nil, nil); err == nil {
+		t.Errorf("AEAD128x4{}.TrySeal succeeded")
+	}
+	if _, err := a128.Open(nil, nonce16, make([]byte, 16), nil); err == nil {
+		t.Errorf("AEAD128x4{}.Open succeeded")
+	}
+	var m128 aegis.Mac128x4
+	mustPanic(t, "Mac128x4{}.Sum16", func() { m128.Sum16(nonce16, nil) })
+	if err := m128.Verify16(nonce16, nil, [16]byte{}); err == nil {
+		t.Errorf("Mac128x4{}.Verify16 succeeded")
+	}
+
+	var a256 aegis.AEAD256x4
+	mustPanic(t, "AEAD256x4{}.Seal", func() { a256.Seal(nil, nonce32, nil, nil) })
+	if _, err := a256.Open(nil, nonce32, make([]byte, 16), nil); err == nil {
+		t.Errorf("AEAD256x4{}.Open succeeded")
+	}
+	var m256 aegis.Mac256x4
+	mustPanic(t, "Mac256x4{}.Sum32", func() { m256.Sum32(nonce32, nil) })
+	if err := m256.Verify32(nonce32, nil, [32]byte{}); err == nil {
+		t.Errorf("Mac256x4{}.Verify32 succeeded")
+	}
+}
@@ -0,0 +1,273 @@
+package aegis_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestAEADConformance(t *testing.T) {
+	t.Run("AEAD128x2", func(t *testing.T) {
+		forEachImplementation(t, func(t *testing.T) {
+			testAEAD(t, func(key []byte) cipher.AEAD { return aegis.NewAEAD128x2([16]byte(key)) }, 16)
+		})
+	})
+	t.Run("AEAD128x2WithTagSize32", func(t *testing.T) {
+		forEachImplementation(t, func(t *testing.T) {
+			testAEAD(t, func(key []byte) cipher.AEAD {
+				aead, err := aegis.NewAEAD128x2WithTagSize([16]byte(key), 32)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return aead
+			}, 16)
+		})
+	})
+}
+
+// testAEAD checks that the AEAD returned by newAEAD follows the contract of
+// crypto/cipher.AEAD, in the manner of the standard library's cryptotest.
+func testAEAD(t *testing.T, newAEAD func(key []byte) cipher.AEAD, keySize int) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	aead := newAEAD(randomBytes(rng, keySize))
+
+	lengths := []int{0, 1, 15, 16, 17, 31, 32, 33, 63, 64, 65, 127, 128, 129, 255, 256, 257, 1000}
+
+	t.Run("Roundtrip", func(t *testing.T) {
+		for _, ptLen := range lengths {
+			for _, adLen := range []int{0, 1, 64, 100} {
+				nonce := randomBytes(rng, aead.NonceSize())
+				plaintext := randomBytes(rng, ptLen)
+				aad := randomBytes(rng, adLen)
+
+				ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+				if len(ciphertext) != ptLen+aead.Overhead() {
+					t.Fatalf("len(Seal(%d bytes)) = %d, want %d", ptLen, len(ciphertext), ptLen+aead.Overhead())
+				}
+
+				got, err := aead.Open(nil, nonce, ciphertext, aad)
+				if err != nil {
+					t.Fatalf("Open(%d, %d): %v", ptLen, adLen, err)
+				}
+				if !bytes.Equal(got, plaintext) {
+					t.Fatalf("Open(%d, %d) = %x, want %x", ptLen, adLen, got, plaintext)
+				}
+			}
+		}
+	})
+
+	t.Run("InputNotModified", func(t *testing.T) {
+		nonce := randomBytes(rng, aead.NonceSize())
+		plaintext := randomBytes(rng, 200)
+		aad := randomBytes(rng, 50)
+
+		nonceCopy := bytes.Clone(nonce)
+		plaintextCopy := bytes.Clone(plaintext)
+		aadCopy := bytes.Clone(aad)
+
+		ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+		ciphertextCopy := bytes.Clone(ciphertext)
+		if _, err := aead.Open(nil, nonce, ciphertext, aad); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(nonce, nonceCopy) || !bytes.Equal(plaintext, plaintextCopy) ||
+			!bytes.Equal(aad, aadCopy) || !bytes.Equal(ciphertext, ciphertextCopy) {
+			t.Error("Seal or Open modified its inputs")
+		}
+	})
+
+	t.Run("InPlace", func(t *testing.T) {
+		for _, ptLen := range lengths {
+			nonce := randomBytes(rng, aead.NonceSize())
+			plaintext := randomBytes(rng, ptLen)
+			aad := randomBytes(rng, 20)
+			want := aead.Seal(nil, nonce, plaintext, aad)
+
+			buf := make([]byte, ptLen, ptLen+aead.Overhead())
+			copy(buf, plaintext)
+			ciphertext := aead.Seal(buf[:0], nonce, buf, aad)
+			if !bytes.Equal(ciphertext, want) {
+				t.Fatalf("in-place Seal(%d bytes) = %x, want %x", ptLen, ciphertext, want)
+			}
+			if &ciphertext[0] != &buf[:1][0] {
+				t.Fatalf("in-place Seal(%d bytes) did not reuse the plaintext buffer", ptLen)
+			}
+
+			got, err := aead.Open(ciphertext[:0], nonce, ciphertext, aad)
+			if err != nil {
+				t.Fatalf("in-place Open(%d bytes): %v", ptLen, err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("in-place Open(%d bytes) = %x, want %x", ptLen, got, plaintext)
+			}
+		}
+	})
+
+	t.Run("AppendDst", func(t *testing.T) {
+		for _, ptLen := range lengths {
+			nonce := randomBytes(rng, aead.NonceSize())
+			plaintext := randomBytes(rng, ptLen)
+			aad := randomBytes(rng, 20)
+			prefix := randomBytes(rng, 1+rng.IntN(40))
+			want := aead.Seal(nil, nonce, plaintext, aad)
+
+			for _, extraCap := range []int{0, ptLen + aead.Overhead()} {
+				dst := make([]byte, len(prefix), len(prefix)+extraCap)
+				copy(dst, prefix)
+
+				ciphertext := aead.Seal(dst, nonce, plaintext, aad)
+				if !bytes.Equal(ciphertext[:len(prefix)], prefix) || !bytes.Equal(ciphertext[len(prefix):], want) {
+					t.Fatalf("Seal(prefix, %d bytes) = %x, want %x || %x", ptLen, ciphertext, prefix, want)
+				}
+				if extraCap > 0 && &ciphertext[0] != &dst[0] {
+					t.Fatalf("Seal(prefix, %d bytes) reallocated despite sufficient capacity", ptLen)
+				}
+
+				dst = make([]byte, len(prefix), len(prefix)+extraCap)
+				copy(dst, prefix)
+
+				got, err := aead.Open(dst, nonce, want, aad)
+				if err != nil {
+					t.Fatalf("Open(prefix, %d bytes): %v", ptLen, err)
+				}
+				if !bytes.Equal(got[:len(prefix)], prefix) || !bytes.Equal(got[len(prefix):], plaintext) {
+					t.Fatalf("Open(prefix, %d bytes) = %x, want %x || %x", ptLen, got, prefix, plaintext)
+				}
+			}
+		}
+	})
+
+	t.Run("BufferOverlap", func(t *testing.T) {
+		nonce := randomBytes(rng, aead.NonceSize())
+		aad := randomBytes(rng, 20)
+
+		for _, ptLen := range []int{1, 64, 200} {
+			for _, offset := range []int{1, 16, ptLen} {
+				buf := make([]byte, 2*ptLen+2*aead.Overhead())
+				plaintext := buf[:ptLen]
+				if offset < ptLen {
+					mustPanic(t, fmt.Sprintf("Seal(%d bytes) with output at +%d", ptLen, offset), func() {
+						aead.Seal(buf[offset:offset], nonce, plaintext, aad)
+					})
+				}
+				mustPanic(t, fmt.Sprintf("Seal(%d bytes) with plaintext at +%d", ptLen, offset), func() {
+					aead.Seal(buf[:0], nonce, buf[offset:offset+ptLen], aad)
+				})
+
+				ciphertext := aead.Seal(nil, nonce, randomBytes(rng, ptLen), aad)
+				copy(buf, ciphertext)
+				mustPanic(t, fmt.Sprintf("Open(%d bytes) with output at +%d", ptLen, offset), func() {
+					aead.Open(buf[offset:offset], nonce, buf[:len(ciphertext)], aad)
+				})
+			}
+
+			buf := make([]byte, ptLen+aead.Overhead()+len(aad))
+			overlapping := buf[ptLen:]
+			copy(overlapping, aad)
+			mustPanic(t, fmt.Sprintf("Seal(%d bytes) with output overlapping aad", ptLen), func() {
+				aead.Seal(buf[:0], nonce, randomBytes(rng, ptLen), overlapping[:len(aad)])
+			})
+			mustPanic(t, fmt.Sprintf("Open(%d bytes) with output overlapping aad", ptLen), func() {
+				aead.Open(buf[:0], nonce, make([]byte, ptLen+aead.Overhead()), buf[:len(aad)])
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		testAEADErrors(t, aead)
+	})
+
+	t.Run("WrongInputs", func(t *testing.T) {
+		nonce := randomBytes(rng, aead.NonceSize())
+		plaintext := randomBytes(rng, 100)
+		aad := randomBytes(rng, 20)
+		ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+
+		if _, err := aead.Open(nil, bytesWithFlippedBit(nonce, 3), ciphertext, aad); err == nil {
+			t.Error("Open succeeded with the wrong nonce")
+		}
+		if _, err := aead.Open(nil, nonce, ciphertext, bytesWithFlippedBit(aad, 3)); err == nil {
+			t.Error("Open succeeded with the wrong additional data")
+		}
+		for _, i := range []int{0, len(plaintext) - 1, len(plaintext), len(ciphertext) - 1} {
+			if _, err := aead.Open(nil, nonce, bytesWithFlippedBit(ciphertext, i), aad); err == nil {
+				t.Errorf("Open succeeded with byte %d of the ciphertext modified", i)
+			}
+		}
+		if _, err := aead.Open(nil, nonce, ciphertext[:aead.Overhead()-1], aad); err == nil {
+			t.Error("Open succeeded with a truncated ciphertext")
+		}
+
+		// A failed in-place Open must not leave plaintext behind.
+		tampered := bytesWithFlippedBit(ciphertext, len(ciphertext)-1)
+		if _, err := aead.Open(tampered[:0], nonce, tampered, aad); err == nil {
+			t.Fatal("in-place Open succeeded with a tampered tag")
+		}
+		if bytes.Contains(tampered, plaintext[:16]) {
+			t.Error("failed in-place Open left plaintext in the buffer")
+		}
+	})
+}
+
+// trySealer is implemented by the AEADs of this package, but not by the
+// cipher.AEAD returned by NewAEAD128x2WithTagSize.
+type trySealer interface {
+	TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error)
+}
+
+func testAEADErrors(t *testing.T, aead cipher.AEAD) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	nonce := randomBytes(rng, aead.NonceSize())
+	plaintext := randomBytes(rng, 100)
+	aad := randomBytes(rng, 20)
+	ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+
+	if _, err := aead.Open(nil, nonce, bytesWithFlippedBit(ciphertext, 0), aad); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("Open(modified ciphertext) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+	if _, err := aead.Open(nil, nonce, ciphertext[:aead.Overhead()-1], aad); !errors.Is(err, aegis.ErrCiphertextTooShort) {
+		t.Errorf("Open(truncated ciphertext) = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+	for _, n := range []int{0, aead.NonceSize() - 1, aead.NonceSize() + 1} {
+		if _, err := aead.Open(nil, make([]byte, n), ciphertext, aad); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+			t.Errorf("Open(%d-byte nonce) = %v, want %v", n, err, aegis.ErrInvalidNonceSize)
+		}
+		mustPanic(t, fmt.Sprintf("Seal(%d-byte nonce)", n), func() {
+			aead.Seal(nil, make([]byte, n), plaintext, aad)
+		})
+	}
+
+	ts, ok := aead.(trySealer)
+	if !ok {
+		return
+	}
+	got, err := ts.TrySeal(nil, nonce, plaintext, aad)
+	if err != nil || !bytes.Equal(got, ciphertext) {
+		t.Errorf("TrySeal = %x, %v, want %x, nil", got, err, ciphertext)
+	}
+	if _, err := ts.TrySeal(nil, nonce[1:], plaintext, aad); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("TrySeal(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+}
+
+func mustPanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s did not panic", name)
+		}
+	}()
+	f()
+}
+
+func bytesWithFlippedBit(b []byte, i int) []byte {
+	ret := append([]byte(nil), b...)
+	ret[i] ^= 0x80
+	return ret
+}
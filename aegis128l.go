@@ -0,0 +1,239 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"simd/archsimd"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+type AEAD128L struct {
+	key [16]byte
+
+	// ok is set by NewAEAD128L. The zero value has skipped its CPU check, so
+	// it must not reach the kernels.
+	ok bool
+}
+
+var _ cipher.AEAD = AEAD128L{}
+
+func NewAEAD128L(key [16]byte) (AEAD128L, error) {
+	if active < implAESNI {
+		return AEAD128L{}, errNoAESNI
+	}
+	return AEAD128L{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that a was created by NewAEAD128L.
+func (a AEAD128L) checkArgs(nonce []byte, msglen, adlen uint64) error {
+	if !a.ok {
+		return errNoAESNI
+	}
+	return checkArgs(nonce, a.NonceSize(), msglen, adlen)
+}
+
+func (a AEAD128L) NonceSize() int {
+	return 16
+}
+
+func (a AEAD128L) Overhead() int {
+	return 16
+}
+
+func (a AEAD128L) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	_, tag := a.DetachedSeal16(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func absorbAad128L(state impl.State128L, aad []byte) impl.State128L {
+	var i int
+	for i = 0; i+32 <= len(aad); i += 32 {
+		m0 := archsimd.LoadUint8x16Slice(aad[i : i+16])
+		m1 := archsimd.LoadUint8x16Slice(aad[i+16 : i+32])
+		state = impl.UpdateState128L(state, m0, m1)
+	}
+
+	if i < len(aad) {
+		var last [32]byte
+		copy(last[:], aad[i:])
+
+		m0 := archsimd.LoadUint8x16Slice(last[0:16])
+		m1 := archsimd.LoadUint8x16Slice(last[16:32])
+		state = impl.UpdateState128L(state, m0, m1)
+	}
+
+	return state
+}
+
+func (a AEAD128L) detachedSeal(dst, nonce, plaintext, aad []byte) ([]byte, impl.State128L) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		panic(err)
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext))
+	checkAliasing(out, plaintext, aad)
+
+	state := impl.InitState128L(archsimd.LoadUint8x16(&a.key), archsimd.LoadUint8x16Slice(nonce))
+	state = absorbAad128L(state, aad)
+
+	// Encrypt blocks.
+	{
+		var i int
+		for i = 0; i+32 <= len(plaintext); i += 32 {
+			p0 := archsimd.LoadUint8x16Slice(plaintext[i : i+16])
+			p1 := archsimd.LoadUint8x16Slice(plaintext[i+16 : i+32])
+
+			var c0, c1 archsimd.Uint8x16
+			state, c0, c1 = impl.Enc128L(state, p0, p1)
+
+			c0.StoreSlice(out[i : i+16])
+			c1.StoreSlice(out[i+16 : i+32])
+		}
+
+		if i < len(plaintext) {
+			var last [32]byte
+			copy(last[:], plaintext[i:])
+
+			p0 := archsimd.LoadUint8x16Slice(last[0:16])
+			p1 := archsimd.LoadUint8x16Slice(last[16:32])
+
+			var c0, c1 archsimd.Uint8x16
+			state, c0, c1 = impl.Enc128L(state, p0, p1)
+
+			c0.StoreSlice(last[0:16])
+			c1.StoreSlice(last[16:32])
+
+			copy(out[i:len(plaintext)], last[:])
+		}
+	}
+
+	return ret, state
+}
+
+func (a AEAD128L) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize128L_16(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+func (a AEAD128L) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize128L_32(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+// TrySeal is like AEAD128x2.TrySeal. It also fails if a was not created by
+// NewAEAD128L.
+func (a AEAD128L) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+	return a.Seal(dst, nonce, plaintext, aad), nil
+}
+
+// TryDetachedSeal16 is like AEAD128x2.TryDetachedSeal16.
+func (a AEAD128L) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [16]byte{}, err
+	}
+	ret, tag := a.DetachedSeal16(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+// TryDetachedSeal32 is like AEAD128x2.TryDetachedSeal32.
+func (a AEAD128L) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [32]byte{}, err
+	}
+	ret, tag := a.DetachedSeal32(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+func (a AEAD128L) detachedOpen(dst, nonce, ciphertext, aad []byte) ([]byte, []byte, impl.State128L) {
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	checkAliasing(out, ciphertext, aad)
+
+	state := impl.InitState128L(archsimd.LoadUint8x16(&a.key), archsimd.LoadUint8x16Slice(nonce))
+	state = absorbAad128L(state, aad)
+
+	// Decrypt blocks.
+	{
+		var i int
+		for i = 0; i+32 <= len(ciphertext); i += 32 {
+			c0 := archsimd.LoadUint8x16Slice(ciphertext[i : i+16])
+			c1 := archsimd.LoadUint8x16Slice(ciphertext[i+16 : i+32])
+
+			var p0, p1 archsimd.Uint8x16
+			state, p0, p1 = impl.Dec128L(state, c0, c1)
+
+			p0.StoreSlice(out[i : i+16])
+			p1.StoreSlice(out[i+16 : i+32])
+		}
+
+		if i < len(ciphertext) {
+			var last [32]byte
+			copy(last[:], ciphertext[i:])
+
+			state, last = impl.DecPartial128L(state, last, len(ciphertext)-i)
+
+			copy(out[i:len(ciphertext)], last[:len(ciphertext)-i])
+		}
+	}
+
+	return ret, out, state
+}
+
+func (a AEAD128L) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize128L_16(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD128L) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize128L_32(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD128L) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	checkAliasing(out, ciphertext, aad)
+
+	tag := ([16]byte)(ciphertext[len(ciphertext)-a.Overhead():])
+	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
+
+	if _, err := a.DetachedOpen16(out[:0], nonce, ciphertext, aad, tag); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
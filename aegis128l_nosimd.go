@@ -0,0 +1,60 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+import "crypto/cipher"
+
+// AEAD128L needs the SIMD kernels, so without them it is only declared, to keep
+// the package API the same on every platform: NewAEAD128L always fails, and
+// every method fails as it does for the zero value.
+type AEAD128L struct{}
+
+var _ cipher.AEAD = AEAD128L{}
+
+func NewAEAD128L(key [16]byte) (AEAD128L, error) {
+	return AEAD128L{}, errNoAESNI
+}
+
+func (a AEAD128L) NonceSize() int {
+	return 16
+}
+
+func (a AEAD128L) Overhead() int {
+	return 16
+}
+
+func (a AEAD128L) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	panic(errNoAESNI)
+}
+
+func (a AEAD128L) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	panic(errNoAESNI)
+}
+
+func (a AEAD128L) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	panic(errNoAESNI)
+}
+
+func (a AEAD128L) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+func (a AEAD128L) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	return nil, [16]byte{}, errNoAESNI
+}
+
+func (a AEAD128L) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	return nil, [32]byte{}, errNoAESNI
+}
+
+func (a AEAD128L) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+func (a AEAD128L) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+func (a AEAD128L) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
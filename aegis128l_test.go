@@ -0,0 +1,224 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+var aegis128LTestCases = []struct {
+	name string
+
+	// Inputs (all hex-encoded)
+	key            string
+	nonce          string
+	plaintext      string
+	additionalData string
+
+	// Expected outputs (all hex-encoded).
+	expectedCiphertext string
+	expectedTag16      string
+	expectedTag32      string
+}{
+	{
+		name: "TestVector1",
+
+		key:            "10010000000000000000000000000000",
+		nonce:          "10000200000000000000000000000000",
+		plaintext:      "00000000000000000000000000000000",
+		additionalData: "",
+
+		expectedCiphertext: "c1c0e58bd913006feba00f4b3cc3594e",
+		expectedTag16:      "abe0ece80c24868a226a35d16bdae37a",
+		expectedTag32: "25835bfbb21632176cf03840687cb968" +
+			"cace4617af1bd0f7d064c639a5c79ee4",
+	},
+
+	{
+		name: "TestVector2",
+
+		key:            "10010000000000000000000000000000",
+		nonce:          "10000200000000000000000000000000",
+		plaintext:      "",
+		additionalData: "",
+
+		expectedCiphertext: "",
+		expectedTag16:      "c2b879a67def9d74e6c14f708bbcc9b4",
+		expectedTag32: "1360dc9db8ae42455f6e5b6a9d488ea4" +
+			"f2184c4e12120249335c4ee84bafe25d",
+	},
+
+	{
+		name: "TestVector3",
+
+		key:   "10010000000000000000000000000000",
+		nonce: "10000200000000000000000000000000",
+		plaintext: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f",
+		additionalData: "0001020304050607",
+
+		expectedCiphertext: "79d94593d8c2119d7e8fd9b8fc77845c" +
+			"5c077a05b2528b6ac54b563aed8efe84",
+		expectedTag16: "cc6f3372f6aa1bb82388d695c3962d9a",
+		expectedTag32: "022cb796fe7e0ae1197525ff67e30948" +
+			"4cfbab6528ddef89f17d74ef8ecd82b3",
+	},
+
+	{
+		name: "TestVector4",
+
+		key:            "10010000000000000000000000000000",
+		nonce:          "10000200000000000000000000000000",
+		plaintext:      "000102030405060708090a0b0c0d",
+		additionalData: "0001020304050607",
+
+		expectedCiphertext: "79d94593d8c2119d7e8fd9b8fc77",
+		expectedTag16:      "5c04b3dba849b2701effbe32c7f0fab7",
+		expectedTag32: "86f1b80bfb463aba711d15405d094baf" +
+			"4a55a15dbfec81a76f35ed0b9c8b04ac",
+	},
+
+	{
+		name: "TestVector5",
+
+		key:   "10010000000000000000000000000000",
+		nonce: "10000200000000000000000000000000",
+		plaintext: "101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f" +
+			"3031323334353637",
+		additionalData: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f" +
+			"20212223242526272829",
+
+		expectedCiphertext: "b31052ad1cca4e291abcf2df3502e6bd" +
+			"b1bfd6db36798be3607b1f94d34478aa" +
+			"7ede7f7a990fec10",
+		expectedTag16: "7542a745733014f9474417b337399507",
+		expectedTag32: "b91e2947a33da8bee89b6794e647baf0" +
+			"fc835ff574aca3fc27c33be0db2aff98",
+	},
+}
+
+func TestAegis128L(t *testing.T) {
+	for _, tc := range aegis128LTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			aead := newAEAD128L(t, ([16]byte)(key))
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+		})
+	}
+}
+
+func TestAegis128LInvalid(t *testing.T) {
+	tc := aegis128LTestCases[3]
+	key := ([16]byte)(unhex(tc.key))
+	nonce := unhex(tc.nonce)
+	ciphertext := unhex(tc.expectedCiphertext + tc.expectedTag16)
+	additionalData := unhex(tc.additionalData)
+
+	if _, err := newAEAD128L(t, key).Open(nil, nonce, ciphertext, additionalData); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+
+	wrongKey := key
+	wrongKey[0] ^= 1
+	if _, err := newAEAD128L(t, wrongKey).Open(nil, nonce, ciphertext, additionalData); err == nil {
+		t.Errorf("expected error with wrong key")
+	}
+
+	wrongAdditionalData := unhex("0001020304050606")
+	if _, err := newAEAD128L(t, key).Open(nil, nonce, ciphertext, wrongAdditionalData); err == nil {
+		t.Errorf("expected error with wrong additional data")
+	}
+
+	for i := range ciphertext {
+		wrongCiphertext := bytesWithFlippedBit(ciphertext, i)
+		if _, err := newAEAD128L(t, key).Open(nil, nonce, wrongCiphertext, additionalData); err == nil {
+			t.Errorf("expected error with bit flipped at byte %d", i)
+		}
+	}
+}
+
+func benchmarkAegis128L(b *testing.B, plaintext []byte) {
+	var key [16]byte
+	var nonce [16]byte
+	ciphertext := make([]byte, len(plaintext)+16)
+
+	b.SetBytes(int64(len(plaintext)))
+
+	aead := newAEAD128L(b, key)
+
+	for b.Loop() {
+		ciphertext = ciphertext[:0]
+		ciphertext = aead.Seal(ciphertext, nonce[:], plaintext, nil)
+	}
+}
+
+func BenchmarkAegis128L(b *testing.B) {
+	for _, length := range []int{64, 16384, 65536} {
+		b.Run(strconv.Itoa(length), func(b *testing.B) {
+			benchmarkAegis128L(b, make([]byte, length))
+		})
+	}
+}
+
+func newAEAD128L(t testing.TB, key [16]byte) aegis.AEAD128L {
+	aead, err := aegis.NewAEAD128L(key)
+	if err != nil {
+		t.Skipf("AEGIS-128L unavailable: %v", err)
+	}
+	return aead
+}
@@ -1,18 +1,16 @@
 package aegis
 
 import (
+	"crypto/cipher"
 	"crypto/subtle"
-	"errors"
-	"simd/archsimd"
-	"slices"
-
-	"github.com/balasanjay/aegis/internal/impl"
 )
 
 type AEAD128x2 struct {
 	key [16]byte
 }
 
+var _ cipher.AEAD = AEAD128x2{}
+
 func NewAEAD128x2(key [16]byte) AEAD128x2 {
 	return AEAD128x2{key}
 }
@@ -26,171 +24,229 @@ func (a AEAD128x2) Overhead() int {
 }
 
 func (a AEAD128x2) Seal(dst, nonce, plaintext, aad []byte) []byte {
-	dst = slices.Grow(dst, len(plaintext)+a.Overhead())
-
-	var tagb [16]byte
-	dst, tagb = a.DetachedSeal16(dst, nonce, plaintext, aad)
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
 
-	dst = append(dst, tagb[:]...)
+	_, tag := a.DetachedSeal16(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext):], tag[:])
 
-	return dst
+	return ret
 }
 
-func absorbAad(state impl.State128x2, aad []byte) impl.State128x2 {
-	var i int
-	for i = 0; i+64 <= len(aad); i += 64 {
-		m0 := archsimd.LoadUint8x32Slice(aad[i : i+32])
-		m1 := archsimd.LoadUint8x32Slice(aad[i+32 : i+64])
-		state = impl.UpdateState128x2(state, m0, m1)
-	}
+func absorbAad(state *state128x2, aad []byte) {
+	n := len(aad) &^ 63
+	state.absorb(aad[:n])
 
-	if i < len(aad) {
+	if n < len(aad) {
 		var last [64]byte
-		copy(last[:], aad[i:])
-
-		m0 := archsimd.LoadUint8x32Slice(last[0:32])
-		m1 := archsimd.LoadUint8x32Slice(last[32:64])
-		state = impl.UpdateState128x2(state, m0, m1)
+		copy(last[:], aad[n:])
+		state.absorb(last[:])
 	}
-
-	return state
 }
 
-func (a AEAD128x2) detachedSeal(dst, nonce, plaintext, aad []byte) ([]byte, impl.State128x2) {
-	if len(nonce) != a.NonceSize() {
-		panic("nonce is incorrect size")
-	}
+// encryptMessage encrypts plaintext into out, which has the same length.
+func encryptMessage(state *state128x2, out, plaintext []byte) {
+	n := len(plaintext) &^ 63
+	state.enc(out[:n], plaintext[:n])
 
-	ret := slices.Grow(dst, len(plaintext))
-	ret = ret[0:len(plaintext)]
-	if len(ret) < len(plaintext) {
-		panic("expected len(ret) >= len(plaintext)")
-	}
-
-	// TODO: panic if ret and plaintext have inexact overlap.
-	// TODO: panic if ret and aad have any overlap.
+	if n < len(plaintext) {
+		var last [64]byte
+		copy(last[:], plaintext[n:])
+		state.enc(last[:], last[:])
 
-	state := impl.InitState128x2(archsimd.LoadUint8x16(&a.key), archsimd.LoadUint8x16Slice(nonce))
-	state = absorbAad(state, aad)
+		copy(out[n:len(plaintext)], last[:])
+	}
+}
 
-	// Encrypt blocks.
-	{
-		var i int
-		for i = 0; i+64 <= len(plaintext); i += 64 {
-			p0 := archsimd.LoadUint8x32Slice(plaintext[i : i+32])
-			p1 := archsimd.LoadUint8x32Slice(plaintext[i+32 : i+64])
+// decryptMessage decrypts ciphertext into out, which has the same length.
+func decryptMessage(state *state128x2, out, ciphertext []byte) {
+	n := len(ciphertext) &^ 63
+	state.dec(out[:n], ciphertext[:n])
 
-			var c0, c1 archsimd.Uint8x32
-			state, c0, c1 = impl.Enc128x2(state, p0, p1)
+	if n < len(ciphertext) {
+		var last [64]byte
+		copy(last[:], ciphertext[n:])
 
-			c0.StoreSlice(ret[i : i+32])
-			c1.StoreSlice(ret[i+32 : i+64])
-		}
+		last = state.decPartial(last, len(ciphertext)-n)
 
-		if i < len(plaintext) {
-			var last [64]byte
-			copy(last[:], plaintext[i:])
+		copy(out[n:len(ciphertext)], last[:len(ciphertext)-n])
+	}
+}
 
-			p0 := archsimd.LoadUint8x32Slice(last[0:32])
-			p1 := archsimd.LoadUint8x32Slice(last[32:64])
+func (a AEAD128x2) detachedSeal(dst, nonce, plaintext, aad []byte) ([]byte, state128x2) {
+	if err := checkArgs(nonce, a.NonceSize(), uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		panic(err)
+	}
 
-			var c0, c1 archsimd.Uint8x32
-			state, c0, c1 = impl.Enc128x2(state, p0, p1)
+	ret, out := sliceForAppend(dst, len(plaintext))
+	checkAliasing(out, plaintext, aad)
 
-			c0.StoreSlice(last[0:32])
-			c1.StoreSlice(last[32:64])
+	var state state128x2
+	state.init(&a.key, (*[16]byte)(nonce))
+	absorbAad(&state, aad)
 
-			copy(ret[i:len(plaintext)], last[:])
-		}
-	}
+	encryptMessage(&state, out, plaintext)
 
 	return ret, state
 }
 
 func (a AEAD128x2) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
 	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
-	tag := impl.Finalize128x2_16(state, uint64(len(aad)), uint64(len(plaintext)))
+	tag := state.finalize16(uint64(len(aad)), uint64(len(plaintext)))
 	return ret, tag
 }
 
 func (a AEAD128x2) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
 	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
-	tag := impl.Finalize128x2_32(state, uint64(len(aad)), uint64(len(plaintext)))
+	tag := state.finalize32(uint64(len(aad)), uint64(len(plaintext)))
 	return ret, tag
 }
 
-func (a AEAD128x2) detachedOpen(dst, nonce, ciphertext, aad []byte) ([]byte, impl.State128x2) {
-	if len(nonce) != a.NonceSize() {
-		panic("nonce is incorrect size")
+// TrySeal is like Seal, but returns an error instead of panicking if the nonce
+// has the wrong size (ErrInvalidNonceSize) or if the plaintext or additional
+// data are too long (ErrMessageTooLong). It writes nothing to dst when it
+// fails.
+//
+// The TrySeal methods of the other AEADs, and their TryDetachedSeal16 and
+// TryDetachedSeal32, follow the same contract.
+func (a AEAD128x2) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	if err := checkArgs(nonce, a.NonceSize(), uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, err
 	}
+	return a.Seal(dst, nonce, plaintext, aad), nil
+}
 
-	ret := slices.Grow(dst, len(ciphertext))
-	ret = ret[:len(ciphertext)]
-
-	// TODO: panic if ret and ciphertext have inexact overlap.
-	// TODO: panic if ret and aad have any overlap.
-
-	state := impl.InitState128x2(archsimd.LoadUint8x16(&a.key), archsimd.LoadUint8x16Slice(nonce))
-	state = absorbAad(state, aad)
-
-	// Decrypt blocks.
-	{
-		var i int
-		for i = 0; i+64 <= len(ciphertext); i += 64 {
-			c0 := archsimd.LoadUint8x32Slice(ciphertext[i : i+32])
-			c1 := archsimd.LoadUint8x32Slice(ciphertext[i+32 : i+64])
-
-			var p0, p1 archsimd.Uint8x32
-			state, p0, p1 = impl.Dec128x2(state, c0, c1)
+// TryDetachedSeal16 is like DetachedSeal16, but returns an error instead of
+// panicking, as TrySeal does.
+func (a AEAD128x2) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	if err := checkArgs(nonce, a.NonceSize(), uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [16]byte{}, err
+	}
+	ret, tag := a.DetachedSeal16(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
 
-			p0.StoreSlice(ret[i : i+32])
-			p1.StoreSlice(ret[i+32 : i+64])
-		}
+// TryDetachedSeal32 is like DetachedSeal32, but returns an error instead of
+// panicking, as TrySeal does.
+func (a AEAD128x2) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	if err := checkArgs(nonce, a.NonceSize(), uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [32]byte{}, err
+	}
+	ret, tag := a.DetachedSeal32(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
 
-		if i < len(ciphertext) {
-			var last [64]byte
-			copy(last[:], ciphertext[i:])
+func (a AEAD128x2) detachedOpen(dst, nonce, ciphertext, aad []byte) ([]byte, []byte, state128x2) {
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	checkAliasing(out, ciphertext, aad)
 
-			state, last = impl.DecPartial128x2(state, last, len(ciphertext)-i)
+	var state state128x2
+	state.init(&a.key, (*[16]byte)(nonce))
+	absorbAad(&state, aad)
 
-			copy(ret[i:len(ciphertext)], last[:len(ciphertext)-i])
-		}
-	}
+	decryptMessage(&state, out, ciphertext)
 
-	return ret, state
+	return ret, out, state
 }
 
 func (a AEAD128x2) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
-	ret, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+	if err := checkArgs(nonce, a.NonceSize(), uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
 
-	expectedTag := impl.Finalize128x2_16(state, uint64(len(aad)), uint64(len(ciphertext)))
+	expectedTag := state.finalize16(uint64(len(aad)), uint64(len(ciphertext)))
 	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
-		clear(ret[:])
-		return nil, errors.New("tag mismatch")
+		clear(out)
+		return nil, ErrAuthFailed
 	}
 	return ret, nil
 }
 
 func (a AEAD128x2) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
-	ret, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+	if err := checkArgs(nonce, a.NonceSize(), uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
 
-	expectedTag := impl.Finalize128x2_32(state, uint64(len(aad)), uint64(len(ciphertext)))
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := state.finalize32(uint64(len(aad)), uint64(len(ciphertext)))
 	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
-		clear(ret[:])
-		return nil, errors.New("tag mismatch")
+		clear(out)
+		return nil, ErrAuthFailed
 	}
 	return ret, nil
 }
 
 func (a AEAD128x2) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
 	if len(ciphertext) < a.Overhead() {
-		return nil, errors.New("ciphertext too small")
+		return nil, ErrCiphertextTooShort
 	}
 
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	checkAliasing(out, ciphertext, aad)
+
 	tag := ([16]byte)(ciphertext[len(ciphertext)-a.Overhead():])
 	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
 
-	return a.DetachedOpen16(dst, nonce, ciphertext, aad, tag)
+	if _, err := a.DetachedOpen16(out[:0], nonce, ciphertext, aad, tag); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// NewAEAD128x2WithTagSize returns an AEGIS-128X2 cipher.AEAD whose Seal and
+// Open use tags of tagSize bytes, which must be 16 or 32. With 16, the result is
+// equivalent to NewAEAD128x2.
+func NewAEAD128x2WithTagSize(key [16]byte, tagSize int) (cipher.AEAD, error) {
+	switch tagSize {
+	case 16:
+		return NewAEAD128x2(key), nil
+	case 32:
+		return aead128x2Tag32{NewAEAD128x2(key)}, nil
+	}
+	return nil, ErrInvalidTagSize
+}
+
+// aead128x2Tag32 is an AEAD128x2 whose Seal and Open use 32-byte tags.
+type aead128x2Tag32 struct {
+	a AEAD128x2
+}
+
+func (a aead128x2Tag32) NonceSize() int {
+	return a.a.NonceSize()
+}
+
+func (a aead128x2Tag32) Overhead() int {
+	return 32
+}
+
+func (a aead128x2Tag32) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	_, tag := a.a.DetachedSeal32(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func (a aead128x2Tag32) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	checkAliasing(out, ciphertext, aad)
+
+	tag := ([32]byte)(ciphertext[len(ciphertext)-a.Overhead():])
+	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
+
+	if _, err := a.a.DetachedOpen32(out[:0], nonce, ciphertext, aad, tag); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 type Mac128x2 struct {
@@ -202,13 +258,55 @@ func NewMac128x2(key [16]byte) Mac128x2 {
 }
 
 func (m Mac128x2) Sum16(nonce []byte, data []byte) [16]byte {
-	state := impl.InitState128x2(archsimd.LoadUint8x16(&m.key), archsimd.LoadUint8x16Slice(nonce))
-	state = absorbAad(state, data)
-	return impl.Finalize128x2Mac_16(state, uint64(len(data)))
+	if err := checkArgs(nonce, 16, 0, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	var state state128x2
+	state.init(&m.key, (*[16]byte)(nonce))
+	absorbAad(&state, data)
+	return state.finalizeMac16(uint64(len(data)))
 }
 
 func (m Mac128x2) Sum32(nonce []byte, data []byte) [32]byte {
-	state := impl.InitState128x2(archsimd.LoadUint8x16(&m.key), archsimd.LoadUint8x16Slice(nonce))
-	state = absorbAad(state, data)
-	return impl.Finalize128x2Mac_32(state, uint64(len(data)))
+	if err := checkArgs(nonce, 16, 0, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	var state state128x2
+	state.init(&m.key, (*[16]byte)(nonce))
+	absorbAad(&state, data)
+	return state.finalizeMac32(uint64(len(data)))
+}
+
+// Verify16 checks that tag is the 16-byte tag of data under nonce, in constant
+// time, and returns ErrAuthFailed if it is not. It returns ErrInvalidNonceSize
+// or ErrMessageTooLong, rather than panicking as Sum16 does, for arguments
+// Sum16 rejects.
+//
+// The Verify16 and Verify32 methods of the other MACs follow the same
+// contract.
+func (m Mac128x2) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	if err := checkArgs(nonce, 16, 0, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum16(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// Verify32 is like Verify16, with 32-byte tags.
+func (m Mac128x2) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	if err := checkArgs(nonce, 16, 0, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum32(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
 }
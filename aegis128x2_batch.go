@@ -0,0 +1,197 @@
+package aegis
+
+import (
+	"crypto/subtle"
+)
+
+// The batch APIs below process many independent messages under one key. When
+// the CPU allows it, two messages at a time share the same vector registers,
+// so that the fixed cost of initialization and finalization, which dominates
+// short messages, is paid once per pair rather than once per message.
+//
+// The outputs of a batch must not overlap each other, or the inputs of any
+// other message in that batch: SealBatch and OpenBatch panic, before writing
+// anything, if they do.
+
+// checkBatch panics unless nonces and inputs hold n entries each, and
+// additionalData either n entries or none.
+func checkBatch(n int, nonces, inputs, additionalData [][]byte) {
+	if len(nonces) != n || len(inputs) != n || (additionalData != nil && len(additionalData) != n) {
+		panic("aegis: batch slices have different lengths")
+	}
+}
+
+// batchAAD returns the additional data of message i.
+func batchAAD(additionalData [][]byte, i int) []byte {
+	if additionalData == nil {
+		return nil
+	}
+	return additionalData[i]
+}
+
+// SealBatch seals every message of a batch, as if by
+//
+//	dst[i] = a.Seal(dst[i], nonces[i], plaintexts[i], additionalData[i])
+//
+// additionalData may be nil if no message has additional data. It panics,
+// before sealing anything, if the slices have different lengths or if any
+// message would make Seal panic.
+func (a AEAD128x2) SealBatch(dst, nonces, plaintexts, additionalData [][]byte) {
+	checkBatch(len(dst), nonces, plaintexts, additionalData)
+	rets, outs, ins := make([][]byte, len(dst)), make([][]byte, len(dst)), make([][][]byte, len(dst))
+	for i := range dst {
+		aad := batchAAD(additionalData, i)
+		if err := checkArgs(nonces[i], a.NonceSize(), uint64(len(plaintexts[i])), uint64(len(aad))); err != nil {
+			panic(err)
+		}
+		rets[i], outs[i] = sliceForAppend(dst[i], len(plaintexts[i])+a.Overhead())
+		checkAliasing(outs[i], plaintexts[i], aad)
+		ins[i] = [][]byte{nonces[i], plaintexts[i], aad}
+	}
+	checkBatchAliasing(outs, ins)
+
+	i := 0
+	if pairBatches() {
+		for ; i+1 < len(dst); i += 2 {
+			var out, nonce, plaintext, aad [2][]byte
+			for j := range 2 {
+				out[j], nonce[j], plaintext[j], aad[j] = outs[i+j], nonces[i+j], plaintexts[i+j], batchAAD(additionalData, i+j)
+			}
+
+			tags := sealPair128x2(&a.key, out, nonce, plaintext, aad)
+			for j := range 2 {
+				copy(out[j][len(plaintext[j]):], tags[j][:])
+				dst[i+j] = rets[i+j]
+			}
+		}
+	}
+	for ; i < len(dst); i++ {
+		// rets[i] has the room for the message, so Seal writes it to outs[i].
+		dst[i] = a.Seal(rets[i][:len(dst[i])], nonces[i], plaintexts[i], batchAAD(additionalData, i))
+	}
+}
+
+// OpenBatch opens every message of a batch, as if by
+//
+//	dst[i], errs[i] = a.Open(dst[i], nonces[i], ciphertexts[i], additionalData[i])
+//
+// except that dst[i] is left unchanged if message i fails to open. It returns
+// nil if every message opened, and otherwise the error of each message, nil
+// for those that opened. additionalData may be nil if no message has
+// additional data. It panics, before opening anything, if the slices have
+// different lengths or if any buffers overlap in a way Open or the batch
+// forbids.
+func (a AEAD128x2) OpenBatch(dst, nonces, ciphertexts, additionalData [][]byte) (errs []error) {
+	checkBatch(len(dst), nonces, ciphertexts, additionalData)
+
+	valid := func(i int) bool {
+		return len(ciphertexts[i]) >= a.Overhead() &&
+			checkArgs(nonces[i], a.NonceSize(), uint64(len(ciphertexts[i])-a.Overhead()), uint64(len(batchAAD(additionalData, i)))) == nil
+	}
+	// Messages that would fail before being decrypted write nothing, so they
+	// have no output to check.
+	rets, outs, ins := make([][]byte, len(dst)), make([][]byte, len(dst)), make([][][]byte, len(dst))
+	for i := range dst {
+		rets[i] = dst[i]
+		if valid(i) {
+			aad := batchAAD(additionalData, i)
+			rets[i], outs[i] = sliceForAppend(dst[i], len(ciphertexts[i])-a.Overhead())
+			checkAliasing(outs[i], ciphertexts[i], aad)
+			ins[i] = [][]byte{nonces[i], ciphertexts[i], aad}
+		}
+	}
+	checkBatchAliasing(outs, ins)
+
+	fail := func(i int, err error) {
+		if errs == nil {
+			errs = make([]error, len(dst))
+		}
+		errs[i] = err
+	}
+	open := func(i int) {
+		// rets[i] has the room for the message, so Open writes it to outs[i].
+		ret, err := a.Open(rets[i][:len(dst[i])], nonces[i], ciphertexts[i], batchAAD(additionalData, i))
+		if err != nil {
+			fail(i, err)
+			return
+		}
+		dst[i] = ret
+	}
+
+	i := 0
+	if pairBatches() {
+		for ; i+1 < len(dst); i += 2 {
+			// Messages that would fail before being decrypted are left to
+			// Open, which reports why.
+			if !valid(i) || !valid(i+1) {
+				open(i)
+				open(i + 1)
+				continue
+			}
+
+			var out, nonce, ciphertext, aad [2][]byte
+			var tag [2][16]byte
+			for j := range 2 {
+				out[j], nonce[j], ciphertext[j], aad[j] = outs[i+j], nonces[i+j], ciphertexts[i+j], batchAAD(additionalData, i+j)
+
+				tag[j] = [16]byte(ciphertext[j][len(ciphertext[j])-a.Overhead():])
+				ciphertext[j] = ciphertext[j][:len(ciphertext[j])-a.Overhead()]
+			}
+
+			expectedTags := openPair128x2(&a.key, out, nonce, ciphertext, aad)
+			for j := range 2 {
+				if subtle.ConstantTimeCompare(expectedTags[j][:], tag[j][:]) != 1 {
+					clear(out[j])
+					fail(i+j, ErrAuthFailed)
+					continue
+				}
+				dst[i+j] = rets[i+j]
+			}
+		}
+	}
+	for ; i < len(dst); i++ {
+		open(i)
+	}
+	return errs
+}
+
+// Sum16Batch sets tags[i] to m.Sum16(nonces[i], data[i]) for every message of
+// a batch. It panics, before computing anything, if the slices have different
+// lengths or if any message would make Sum16 panic.
+func (m Mac128x2) Sum16Batch(tags [][16]byte, nonces, data [][]byte) {
+	m.checkBatch(len(tags), nonces, data)
+
+	i := 0
+	if pairBatches() {
+		for ; i+1 < len(tags); i += 2 {
+			tags[i], tags[i+1] = macPair128x2_16(&m.key, [2][]byte(nonces[i:]), [2][]byte(data[i:]))
+		}
+	}
+	for ; i < len(tags); i++ {
+		tags[i] = m.Sum16(nonces[i], data[i])
+	}
+}
+
+// Sum32Batch is like Sum16Batch, with 32-byte tags.
+func (m Mac128x2) Sum32Batch(tags [][32]byte, nonces, data [][]byte) {
+	m.checkBatch(len(tags), nonces, data)
+
+	i := 0
+	if pairBatches() {
+		for ; i+1 < len(tags); i += 2 {
+			tags[i], tags[i+1] = macPair128x2_32(&m.key, [2][]byte(nonces[i:]), [2][]byte(data[i:]))
+		}
+	}
+	for ; i < len(tags); i++ {
+		tags[i] = m.Sum32(nonces[i], data[i])
+	}
+}
+
+func (m Mac128x2) checkBatch(n int, nonces, data [][]byte) {
+	checkBatch(n, nonces, data, nil)
+	for i := range n {
+		if err := checkArgs(nonces[i], 16, 0, uint64(len(data[i]))); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+// pairBatches reports whether batches should be processed two messages at a
+// time, which takes 512-bit registers.
+func pairBatches() bool {
+	return false
+}
+
+func sealPair128x2(key *[16]byte, out, nonce, plaintext, aad [2][]byte) [2][16]byte {
+	panic("unreachable")
+}
+
+func openPair128x2(key *[16]byte, out, nonce, ciphertext, aad [2][]byte) [2][16]byte {
+	panic("unreachable")
+}
+
+func macPair128x2_16(key *[16]byte, nonce, data [2][]byte) ([16]byte, [16]byte) {
+	panic("unreachable")
+}
+
+func macPair128x2_32(key *[16]byte, nonce, data [2][]byte) ([32]byte, [32]byte) {
+	panic("unreachable")
+}
@@ -0,0 +1,128 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import (
+	"simd/archsimd"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+// pairBatches reports whether batches should be processed two messages at a
+// time. A pair of AEGIS-128X2 states takes 512-bit registers.
+func pairBatches() bool {
+	return active == implVAES512
+}
+
+func initPair128x2(key *[16]byte, nonce [2][]byte) impl.State128x4 {
+	return impl.InitPair128x2(archsimd.LoadUint8x16(key), archsimd.LoadUint8x16Slice(nonce[0]), archsimd.LoadUint8x16Slice(nonce[1]))
+}
+
+// pairBlocks returns the number of 64-byte blocks, including a final partial
+// one, that both messages have.
+func pairBlocks(a, b []byte) int {
+	return min(len(a)+63, len(b)+63) / 64
+}
+
+// pairBlock fills blk with block k of each message, zero-padded.
+func pairBlock(blk *[128]byte, src [2][]byte, k int) {
+	*blk = [128]byte{}
+	copy(blk[0:64], src[0][64*k:])
+	copy(blk[64:128], src[1][64*k:])
+}
+
+// finishAlone runs f on the state of the longer message of a pair, once the
+// shorter one has run out of blocks.
+func finishAlone(state impl.State128x4, src [2][]byte, k int, f func(s *state128x2, j int)) impl.State128x4 {
+	j := 0
+	if len(src[1]) > len(src[0]) {
+		j = 1
+	}
+	if len(src[j]) <= 64*k {
+		return state
+	}
+
+	var pair [2]impl.State128x2
+	pair[0], pair[1] = impl.SplitPair128x2(state)
+
+	var s state128x2
+	impl.StoreState128x2(&s.w, pair[j])
+	f(&s, j)
+	pair[j] = impl.LoadState128x2(&s.w)
+
+	return impl.JoinPair128x2(pair[0], pair[1])
+}
+
+func absorbPair128x2(state impl.State128x4, aad [2][]byte) impl.State128x4 {
+	var blk [128]byte
+	k := pairBlocks(aad[0], aad[1])
+	for i := range k {
+		pairBlock(&blk, aad, i)
+		state = impl.AbsorbPair128x2(state, &blk)
+	}
+
+	return finishAlone(state, aad, k, func(s *state128x2, j int) {
+		absorbAad(s, aad[j][64*k:])
+	})
+}
+
+func sealPair128x2(key *[16]byte, out, nonce, plaintext, aad [2][]byte) [2][16]byte {
+	state := initPair128x2(key, nonce)
+	state = absorbPair128x2(state, aad)
+
+	var blk, c [128]byte
+	k := pairBlocks(plaintext[0], plaintext[1])
+	for i := range k {
+		pairBlock(&blk, plaintext, i)
+		state, c = impl.EncPair128x2(state, &blk)
+		copy(out[0][64*i:len(plaintext[0])], c[0:64])
+		copy(out[1][64*i:len(plaintext[1])], c[64:128])
+	}
+
+	state = finishAlone(state, plaintext, k, func(s *state128x2, j int) {
+		encryptMessage(s, out[j][64*k:len(plaintext[j])], plaintext[j][64*k:])
+	})
+
+	var tags [2][16]byte
+	tags[0], tags[1] = impl.FinalizePair128x2_16(state,
+		uint64(len(aad[0])), uint64(len(plaintext[0])),
+		uint64(len(aad[1])), uint64(len(plaintext[1])))
+	return tags
+}
+
+// openPair128x2 decrypts both messages and returns their expected tags.
+func openPair128x2(key *[16]byte, out, nonce, ciphertext, aad [2][]byte) [2][16]byte {
+	state := initPair128x2(key, nonce)
+	state = absorbPair128x2(state, aad)
+
+	var blk, p [128]byte
+	k := pairBlocks(ciphertext[0], ciphertext[1])
+	for i := range k {
+		pairBlock(&blk, ciphertext, i)
+		clenA := min(64, len(ciphertext[0])-64*i)
+		clenB := min(64, len(ciphertext[1])-64*i)
+		state, p = impl.DecPair128x2(state, &blk, clenA, clenB)
+		copy(out[0][64*i:], p[0:clenA])
+		copy(out[1][64*i:], p[64:64+clenB])
+	}
+
+	state = finishAlone(state, ciphertext, k, func(s *state128x2, j int) {
+		decryptMessage(s, out[j][64*k:], ciphertext[j][64*k:])
+	})
+
+	var tags [2][16]byte
+	tags[0], tags[1] = impl.FinalizePair128x2_16(state,
+		uint64(len(aad[0])), uint64(len(ciphertext[0])),
+		uint64(len(aad[1])), uint64(len(ciphertext[1])))
+	return tags
+}
+
+func macPair128x2_16(key *[16]byte, nonce, data [2][]byte) ([16]byte, [16]byte) {
+	state := absorbPair128x2(initPair128x2(key, nonce), data)
+	return impl.FinalizePair128x2Mac_16(state, uint64(len(data[0])), uint64(len(data[1])))
+}
+
+func macPair128x2_32(key *[16]byte, nonce, data [2][]byte) ([32]byte, [32]byte) {
+	state := absorbPair128x2(initPair128x2(key, nonce), data)
+	return impl.FinalizePair128x2Mac_32(state, uint64(len(data[0])), uint64(len(data[1])))
+}
@@ -0,0 +1,203 @@
+package aegis_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// randomBatch returns n messages, where neighbours sometimes have the same
+// length, so that pairs both stay in step and run out of blocks at different
+// times.
+func randomBatch(rng *rand.Rand, n int) (nonces, messages, additionalData [][]byte) {
+	for i := range n {
+		nonces = append(nonces, randomBytes(rng, 16))
+		if i%2 == 1 && rng.IntN(2) == 0 {
+			messages = append(messages, randomBytes(rng, len(messages[i-1])))
+			additionalData = append(additionalData, randomBytes(rng, len(additionalData[i-1])))
+			continue
+		}
+		messages = append(messages, randomBytes(rng, rng.IntN(300)))
+		additionalData = append(additionalData, randomBytes(rng, rng.IntN(150)))
+	}
+	return nonces, messages, additionalData
+}
+
+func TestAegis128x2Batch(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(27, 28))
+		for _, n := range []int{0, 1, 2, 3, 16, 33} {
+			aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+			nonces, plaintexts, additionalData := randomBatch(rng, n)
+
+			// Seal into fresh buffers, and behind existing prefixes.
+			sealed := make([][]byte, n)
+			for i := range sealed {
+				if i%3 == 0 {
+					sealed[i] = []byte("prefix")
+				}
+			}
+			aead.SealBatch(sealed, nonces, plaintexts, additionalData)
+			for i := range n {
+				var want []byte
+				if i%3 == 0 {
+					want = []byte("prefix")
+				}
+				want = aead.Seal(want, nonces[i], plaintexts[i], additionalData[i])
+				if !bytes.Equal(sealed[i], want) {
+					t.Fatalf("n=%d: SealBatch message %d = %x, want %x", n, i, sealed[i], want)
+				}
+				if i%3 == 0 {
+					sealed[i] = sealed[i][len("prefix"):]
+				}
+			}
+
+			opened := make([][]byte, n)
+			if errs := aead.OpenBatch(opened, nonces, sealed, additionalData); errs != nil {
+				t.Fatalf("n=%d: OpenBatch() = %v", n, errs)
+			}
+			for i := range n {
+				if !bytes.Equal(opened[i], plaintexts[i]) {
+					t.Fatalf("n=%d: OpenBatch message %d = %x, want %x", n, i, opened[i], plaintexts[i])
+				}
+			}
+		}
+	})
+}
+
+func TestAegis128x2BatchNoAdditionalData(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(29, 30))
+		aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+		nonces, plaintexts, _ := randomBatch(rng, 5)
+
+		sealed := make([][]byte, len(plaintexts))
+		aead.SealBatch(sealed, nonces, plaintexts, nil)
+		for i := range sealed {
+			if want := aead.Seal(nil, nonces[i], plaintexts[i], nil); !bytes.Equal(sealed[i], want) {
+				t.Fatalf("SealBatch message %d = %x, want %x", i, sealed[i], want)
+			}
+		}
+		if errs := aead.OpenBatch(make([][]byte, len(sealed)), nonces, sealed, nil); errs != nil {
+			t.Fatalf("OpenBatch() = %v", errs)
+		}
+	})
+}
+
+func TestAegis128x2OpenBatchErrors(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(31, 32))
+		aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+		nonces, plaintexts, additionalData := randomBatch(rng, 8)
+		sealed := make([][]byte, len(plaintexts))
+		aead.SealBatch(sealed, nonces, plaintexts, additionalData)
+
+		// Break some messages, in various ways, and leave the rest alone.
+		want := make([]error, len(sealed))
+		sealed[1] = bytesWithFlippedBit(sealed[1], len(sealed[1])-1)
+		want[1] = aegis.ErrAuthFailed
+		sealed[2] = sealed[2][:10]
+		want[2] = aegis.ErrCiphertextTooShort
+		additionalData[5] = append(additionalData[5], 0)
+		want[5] = aegis.ErrAuthFailed
+		nonces[6] = nonces[6][:12]
+		want[6] = aegis.ErrInvalidNonceSize
+
+		opened := make([][]byte, len(sealed))
+		opened[1] = []byte("untouched")
+		errs := aead.OpenBatch(opened, nonces, sealed, additionalData)
+		if len(errs) != len(sealed) {
+			t.Fatalf("OpenBatch() returned %d errors, want %d", len(errs), len(sealed))
+		}
+		for i := range sealed {
+			if !errors.Is(errs[i], want[i]) || (want[i] == nil) != (errs[i] == nil) {
+				t.Errorf("message %d: error %v, want %v", i, errs[i], want[i])
+			}
+			if want[i] == nil && !bytes.Equal(opened[i], plaintexts[i]) {
+				t.Errorf("message %d: opened %x, want %x", i, opened[i], plaintexts[i])
+			}
+		}
+		if string(opened[1]) != "untouched" {
+			t.Errorf("failed message changed dst to %q", opened[1])
+		}
+	})
+}
+
+func TestAegis128x2BatchMisuse(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{})
+	mac := aegis.NewMac128x2([16]byte{})
+	two := [][]byte{make([]byte, 16), make([]byte, 16)}
+	one := [][]byte{make([]byte, 16)}
+
+	mustPanic(t, "SealBatch with mismatched lengths", func() { aead.SealBatch(make([][]byte, 2), two, one, nil) })
+	mustPanic(t, "SealBatch with mismatched additional data", func() { aead.SealBatch(make([][]byte, 2), two, two, one) })
+	mustPanic(t, "OpenBatch with mismatched lengths", func() { aead.OpenBatch(make([][]byte, 2), one, two, nil) })
+	mustPanic(t, "Sum16Batch with mismatched lengths", func() { mac.Sum16Batch(make([][16]byte, 1), two, two) })
+
+	// A bad nonce anywhere panics before anything is sealed.
+	dst := make([][]byte, 2)
+	mustPanic(t, "SealBatch with a short nonce", func() { aead.SealBatch(dst, [][]byte{make([]byte, 16), nil}, two, nil) })
+	if dst[0] != nil {
+		t.Errorf("SealBatch sealed message 0 before panicking")
+	}
+	mustPanic(t, "Sum32Batch with a short nonce", func() { mac.Sum32Batch(make([][32]byte, 2), [][]byte{nil, nil}, two) })
+}
+
+func TestAegis128x2BatchAliasing(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{})
+	buf := make([]byte, 2000)
+	nonces := [][]byte{buf[1900:1916], buf[1920:1936], buf[1940:1956]}
+	plaintexts := [][]byte{buf[0:100], buf[200:300], buf[400:500]}
+	aad := [][]byte{buf[1800:1810], buf[1830:1840], buf[1700:1710]}
+
+	// Each message may be sealed and opened in place.
+	dst := [][]byte{buf[0:0], buf[200:200], buf[400:400]}
+	aead.SealBatch(dst, nonces, plaintexts, aad)
+	ciphertexts := slices.Clone(dst)
+	if errs := aead.OpenBatch([][]byte{buf[0:0], buf[200:200], buf[400:400]}, nonces, ciphertexts, aad); errs != nil {
+		t.Fatalf("OpenBatch in place: %v", errs)
+	}
+
+	// In each case, the output of the last message, which is not part of a
+	// pair, overlaps a buffer of another message.
+	tcs := []struct {
+		name string
+		dst  [][]byte
+	}{
+		{"OutputOverInput", [][]byte{buf[0:0], buf[200:200], buf[50:50]}},
+		{"OutputOverOutput", [][]byte{buf[600:600], buf[200:200], buf[650:650]}},
+		{"OutputOverNonce", [][]byte{buf[0:0], buf[200:200], buf[1850:1850]}},
+		{"OutputOverAdditionalData", [][]byte{buf[0:0], buf[200:200], buf[1750:1750]}},
+	}
+	for _, tc := range tcs {
+		mustPanic(t, "SealBatch "+tc.name, func() { aead.SealBatch(slices.Clone(tc.dst), nonces, plaintexts, aad) })
+		mustPanic(t, "OpenBatch "+tc.name, func() { aead.OpenBatch(slices.Clone(tc.dst), nonces, ciphertexts, aad) })
+	}
+}
+
+func TestAegisMac128x2Batch(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(33, 34))
+		for _, n := range []int{0, 1, 2, 7, 16} {
+			mac := aegis.NewMac128x2(([16]byte)(randomBytes(rng, 16)))
+			nonces, data, _ := randomBatch(rng, n)
+
+			tags16 := make([][16]byte, n)
+			tags32 := make([][32]byte, n)
+			mac.Sum16Batch(tags16, nonces, data)
+			mac.Sum32Batch(tags32, nonces, data)
+			for i := range n {
+				if want := mac.Sum16(nonces[i], data[i]); tags16[i] != want {
+					t.Fatalf("n=%d: Sum16Batch message %d = %x, want %x", n, i, tags16[i], want)
+				}
+				if want := mac.Sum32(nonces[i], data[i]); tags32[i] != want {
+					t.Fatalf("n=%d: Sum32Batch message %d = %x, want %x", n, i, tags32[i], want)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// CommittingAEAD128x2 is AEGIS-128X2 with key commitment: a ciphertext opens
+// under at most one key, so it cannot be crafted to decrypt under two keys as
+// in partitioning-oracle attacks. The 16-byte tag of AEAD128x2 alone does not
+// commit to the key.
+//
+// Each message is sealed with AEAD128x2 under a subkey, the first 16 bytes of
+// HMAC-SHA256(key, "aegis subkey" || nonce), and followed by a commitment,
+// HMAC-SHA256(key, "aegis commitment" || nonce), which Open checks before
+// anything else. HMAC-SHA256 is collision resistant in its key, unlike
+// Mac128x2, so finding two keys with the same commitment takes about 2^128
+// work. The commitment makes ciphertexts CommitmentSize bytes longer, for an
+// Overhead of 48 bytes.
+type CommittingAEAD128x2 struct {
+	key [16]byte
+}
+
+var _ cipher.AEAD = CommittingAEAD128x2{}
+
+// CommitmentSize is the size of the key commitment that CommittingAEAD128x2
+// appends to every ciphertext, after the tag.
+const CommitmentSize = sha256.Size
+
+func NewCommittingAEAD128x2(key [16]byte) CommittingAEAD128x2 {
+	return CommittingAEAD128x2{key}
+}
+
+func (a CommittingAEAD128x2) NonceSize() int {
+	return 16
+}
+
+func (a CommittingAEAD128x2) Overhead() int {
+	return 16 + CommitmentSize
+}
+
+// derive returns the AEAD128x2 that seals messages under nonce, and their
+// commitment.
+func (a CommittingAEAD128x2) derive(nonce []byte) (AEAD128x2, [CommitmentSize]byte) {
+	mac := hmac.New(sha256.New, a.key[:])
+	mac.Write([]byte("aegis subkey"))
+	mac.Write(nonce)
+	subkey := ([16]byte)(mac.Sum(nil))
+
+	mac.Reset()
+	mac.Write([]byte("aegis commitment"))
+	mac.Write(nonce)
+	commitment := ([CommitmentSize]byte)(mac.Sum(nil))
+
+	return NewAEAD128x2(subkey), commitment
+}
+
+func (a CommittingAEAD128x2) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != a.NonceSize() {
+		panic(ErrInvalidNonceSize)
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	sub, commitment := a.derive(nonce)
+	sub.Seal(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext)+16:], commitment[:])
+
+	return ret
+}
+
+func (a CommittingAEAD128x2) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != a.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	sub, commitment := a.derive(nonce)
+	n := len(ciphertext) - CommitmentSize
+	if subtle.ConstantTimeCompare(commitment[:], ciphertext[n:]) != 1 {
+		return nil, ErrAuthFailed
+	}
+	return sub.Open(dst, nonce, ciphertext[:n], aad)
+}
@@ -0,0 +1,108 @@
+package aegis_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestCommittingAEAD128x2(t *testing.T) {
+	rng := rand.New(rand.NewPCG(101, 102))
+	key := ([16]byte)(randomBytes(rng, 16))
+	aead := aegis.NewCommittingAEAD128x2(key)
+	if aead.NonceSize() != 16 || aead.Overhead() != 48 {
+		t.Fatalf("NonceSize() = %d, Overhead() = %d; want 16, 48", aead.NonceSize(), aead.Overhead())
+	}
+
+	hmacSHA256 := func(label string, nonce []byte) []byte {
+		mac := hmac.New(sha256.New, key[:])
+		mac.Write([]byte(label))
+		mac.Write(nonce)
+		return mac.Sum(nil)
+	}
+
+	for _, length := range []int{0, 1, 63, 64, 65, 1000} {
+		nonce := randomBytes(rng, 16)
+		plaintext, ad := randomBytes(rng, length), randomBytes(rng, length/3)
+
+		sealed := aead.Seal(nil, nonce, plaintext, ad)
+		if len(sealed) != length+aead.Overhead() {
+			t.Fatalf("len=%d: Seal returned %d bytes", length, len(sealed))
+		}
+
+		// The output is AEAD128x2 under the subkey, followed by the
+		// commitment.
+		subkey := ([16]byte)(hmacSHA256("aegis subkey", nonce))
+		want := aegis.NewAEAD128x2(subkey).Seal(nil, nonce, plaintext, ad)
+		want = append(want, hmacSHA256("aegis commitment", nonce)...)
+		if !bytes.Equal(sealed, want) {
+			t.Errorf("len=%d: Seal() does not follow the construction", length)
+		}
+
+		got, err := aead.Open(nil, nonce, sealed, ad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("len=%d: Open() = %x, %v", length, got, err)
+		}
+		inPlace := bytes.Clone(sealed)
+		got, err = aead.Open(inPlace[:0], nonce, inPlace, ad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("len=%d: in-place Open() = %x, %v", length, got, err)
+		}
+
+		for _, i := range []int{0, length, length + 15, length + 16, len(sealed) - 1} {
+			if i >= len(sealed) {
+				continue
+			}
+			if _, err := aead.Open(nil, nonce, bytesWithFlippedBit(sealed, i), ad); err != aegis.ErrAuthFailed {
+				t.Errorf("len=%d: flipped byte %d: err = %v, want %v", length, i, err, aegis.ErrAuthFailed)
+			}
+		}
+	}
+}
+
+func TestCommittingAEAD128x2SecondKey(t *testing.T) {
+	rng := rand.New(rand.NewPCG(103, 104))
+	key1, key2 := ([16]byte)(randomBytes(rng, 16)), ([16]byte)(randomBytes(rng, 16))
+	aead1, aead2 := aegis.NewCommittingAEAD128x2(key1), aegis.NewCommittingAEAD128x2(key2)
+	nonce, plaintext := randomBytes(rng, 16), randomBytes(rng, 100)
+
+	sealed1 := aead1.Seal(nil, nonce, plaintext, nil)
+	sealed2 := aead2.Seal(nil, nonce, plaintext, nil)
+	if _, err := aead2.Open(nil, nonce, sealed1, nil); err != aegis.ErrAuthFailed {
+		t.Errorf("opening under the second key: err = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+
+	// A ciphertext that would be valid under the second key, but carries the
+	// commitment to the first, opens under neither.
+	n := len(sealed2) - aegis.CommitmentSize
+	spliced := append(bytes.Clone(sealed2[:n]), sealed1[n:]...)
+	for i, aead := range []aegis.CommittingAEAD128x2{aead1, aead2} {
+		if _, err := aead.Open(nil, nonce, spliced, nil); err != aegis.ErrAuthFailed {
+			t.Errorf("opening a spliced ciphertext under key %d: err = %v, want %v", i+1, err, aegis.ErrAuthFailed)
+		}
+	}
+
+	// The commitment depends on the key and the nonce, and nothing else.
+	other := aead1.Seal(nil, nonce, randomBytes(rng, 10), []byte("ad"))
+	if !bytes.Equal(other[len(other)-aegis.CommitmentSize:], sealed1[n:]) {
+		t.Errorf("commitment depends on the message")
+	}
+	if bytes.Equal(sealed1[n:], sealed2[n:]) {
+		t.Errorf("two keys have the same commitment")
+	}
+}
+
+func TestCommittingAEAD128x2Errors(t *testing.T) {
+	aead := aegis.NewCommittingAEAD128x2([16]byte{})
+	mustPanic(t, "Seal with a short nonce", func() { aead.Seal(nil, make([]byte, 15), nil, nil) })
+	if _, err := aead.Open(nil, make([]byte, 15), make([]byte, 48), nil); err != aegis.ErrInvalidNonceSize {
+		t.Errorf("short nonce: err = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, err := aead.Open(nil, make([]byte, 16), make([]byte, 47), nil); err != aegis.ErrCiphertextTooShort {
+		t.Errorf("short ciphertext: err = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+}
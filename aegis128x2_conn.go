@@ -0,0 +1,487 @@
+package aegis
+
+import (
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Conn is an encrypted connection over a net.Conn, authenticated with a
+// pre-shared key. It is created with Client or Server.
+//
+// The handshake exchanges a random value in each direction and derives a key
+// and an IV for each direction from the pre-shared key and both random values
+// with HKDF-SHA256, so every connection uses fresh keys. Each side then sends a
+// Finished record, encrypted under its new keys, which its peer must be able
+// to open: a peer that does not know the pre-shared key fails the handshake.
+//
+// After the handshake, data is sent as a sequence of records, each of which is
+// sealed with AEAD128x2. The header of a record, which holds its type and
+// length, is its additional data, and its nonce is the IV of its direction
+// XORed with the number of records sent before it under the same key, in the
+// style of TLS 1.3, so records cannot be modified, dropped, reordered or
+// replayed without the peer noticing. The writer replaces its key before
+// Config.RecordsPerKey records are sent under it, by sending a key update
+// record and deriving the next key from the current one.
+//
+// Closing a Conn sends a close_notify record. A Read that reaches the end of
+// the underlying connection without receiving one returns
+// io.ErrUnexpectedEOF, so a truncated stream is not mistaken for a complete
+// one.
+//
+// A Conn is safe for use by one reader and one writer at the same time.
+type Conn struct {
+	conn     net.Conn
+	config   *Config
+	isClient bool
+
+	handshakeMu       sync.Mutex
+	handshakeErr      error
+	handshakeDone     bool
+	handshakeComplete atomic.Bool
+
+	in, out halfConn
+
+	// Guarded by in.mu.
+	raw   [recordHeaderSize + maxRecordPayload + 16]byte
+	input []byte
+
+	// Guarded by out.mu.
+	outBuf          []byte
+	closeNotifySent bool
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+// Config configures a Conn.
+type Config struct {
+	// PSK is the pre-shared key that authenticates both ends of the
+	// connection. It must be at least 16 bytes of uniformly random data.
+	PSK []byte
+
+	// RecordsPerKey is the number of records a Conn sends under one key
+	// before replacing it. Zero means DefaultRecordsPerKey. It must not be
+	// one.
+	RecordsPerKey uint64
+}
+
+// DefaultRecordsPerKey is the number of records sent under one key when
+// Config.RecordsPerKey is zero.
+const DefaultRecordsPerKey = 1 << 32
+
+const (
+	recordHeaderSize = 3
+	maxRecordPayload = 16 << 10
+
+	recordTypeFinished    = 1
+	recordTypeData        = 2
+	recordTypeKeyUpdate   = 3
+	recordTypeCloseNotify = 4
+
+	helloSize = 8 + 32
+
+	clientHelloMagic = "aegisc01"
+	serverHelloMagic = "aegiss01"
+)
+
+var (
+	errConnConfig      = errors.New("aegis: invalid Config")
+	errConnHello       = errors.New("aegis: peer did not send a valid hello")
+	errConnRecord      = errors.New("aegis: received an invalid record")
+	errConnClosed      = errors.New("aegis: write after close_notify")
+	errConnNotComplete = errors.New("aegis: handshake not complete")
+)
+
+// Client returns a new client side of an encrypted connection over conn.
+// The handshake runs on the first Read or Write, or on a call to Handshake.
+func Client(conn net.Conn, config *Config) *Conn {
+	return &Conn{conn: conn, config: config, isClient: true}
+}
+
+// Server returns a new server side of an encrypted connection over conn.
+// The handshake runs on the first Read or Write, or on a call to Handshake.
+func Server(conn net.Conn, config *Config) *Conn {
+	return &Conn{conn: conn, config: config}
+}
+
+// halfConn holds the state of one direction of a Conn.
+type halfConn struct {
+	mu     sync.Mutex
+	err    error // sticky error
+	aead   AEAD128x2
+	iv     [16]byte
+	secret []byte
+	seq    uint64
+}
+
+func (hc *halfConn) setSecret(secret []byte) {
+	key, _ := hkdf.Expand(sha256.New, secret, "aegis conn key", 16)
+	iv, _ := hkdf.Expand(sha256.New, secret, "aegis conn iv", 16)
+	hc.aead = NewAEAD128x2([16]byte(key))
+	copy(hc.iv[:], iv)
+	hc.secret = secret
+	hc.seq = 0
+}
+
+// update replaces the key of hc with the next one.
+func (hc *halfConn) update() {
+	next, _ := hkdf.Expand(sha256.New, hc.secret, "aegis conn update", 32)
+	hc.setSecret(next)
+}
+
+func (hc *halfConn) nonce() [16]byte {
+	nonce := hc.iv
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], hc.seq)
+	for i := range seq {
+		nonce[8+i] ^= seq[i]
+	}
+	return nonce
+}
+
+func (c *Conn) recordsPerKey() uint64 {
+	if c.config.RecordsPerKey == 0 {
+		return DefaultRecordsPerKey
+	}
+	return c.config.RecordsPerKey
+}
+
+// Handshake runs the handshake if it has not run yet. Most uses of Conn need
+// not call it, as Read and Write run the handshake themselves.
+func (c *Conn) Handshake() error {
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+
+	if c.handshakeDone {
+		return c.handshakeErr
+	}
+	c.handshakeDone = true
+
+	if c.config == nil || len(c.config.PSK) < 16 || c.config.RecordsPerKey == 1 {
+		c.handshakeErr = errConnConfig
+		return c.handshakeErr
+	}
+
+	c.in.mu.Lock()
+	defer c.in.mu.Unlock()
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+
+	if c.isClient {
+		c.handshakeErr = c.clientHandshake()
+	} else {
+		c.handshakeErr = c.serverHandshake()
+	}
+	if c.handshakeErr != nil {
+		c.in.err = c.handshakeErr
+		c.out.err = c.handshakeErr
+		return c.handshakeErr
+	}
+	c.handshakeComplete.Store(true)
+	return nil
+}
+
+func (c *Conn) clientHandshake() error {
+	clientHello := newHello(clientHelloMagic)
+	if _, err := c.conn.Write(clientHello); err != nil {
+		return err
+	}
+	serverHello, err := c.readHello(serverHelloMagic)
+	if err != nil {
+		return err
+	}
+	c.deriveSecrets(clientHello, serverHello)
+
+	if err := c.readFinished(); err != nil {
+		return err
+	}
+	return c.writeRecord(recordTypeFinished, nil)
+}
+
+func (c *Conn) serverHandshake() error {
+	clientHello, err := c.readHello(clientHelloMagic)
+	if err != nil {
+		return err
+	}
+	serverHello := newHello(serverHelloMagic)
+	if _, err := c.conn.Write(serverHello); err != nil {
+		return err
+	}
+	c.deriveSecrets(clientHello, serverHello)
+
+	if err := c.writeRecord(recordTypeFinished, nil); err != nil {
+		return err
+	}
+	return c.readFinished()
+}
+
+func newHello(magic string) []byte {
+	hello := make([]byte, helloSize)
+	copy(hello, magic)
+	rand.Read(hello[len(magic):])
+	return hello
+}
+
+func (c *Conn) readHello(magic string) ([]byte, error) {
+	hello := make([]byte, helloSize)
+	if _, err := io.ReadFull(c.conn, hello); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if string(hello[:len(magic)]) != magic {
+		return nil, errConnHello
+	}
+	return hello, nil
+}
+
+// deriveSecrets sets the traffic secrets of both directions from the
+// pre-shared key and the two hellos.
+func (c *Conn) deriveSecrets(clientHello, serverHello []byte) {
+	salt := append(clientHello[:len(clientHello):len(clientHello)], serverHello...)
+	prk, _ := hkdf.Extract(sha256.New, c.config.PSK, salt)
+	clientSecret, _ := hkdf.Expand(sha256.New, prk, "aegis conn client", 32)
+	serverSecret, _ := hkdf.Expand(sha256.New, prk, "aegis conn server", 32)
+	if c.isClient {
+		c.out.setSecret(clientSecret)
+		c.in.setSecret(serverSecret)
+	} else {
+		c.out.setSecret(serverSecret)
+		c.in.setSecret(clientSecret)
+	}
+}
+
+func (c *Conn) readFinished() error {
+	typ, payload, err := c.readRecord()
+	if err != nil {
+		return err
+	}
+	if typ != recordTypeFinished || len(payload) != 0 {
+		return errConnRecord
+	}
+	return nil
+}
+
+// writeRecord seals payload, which must be at most maxRecordPayload bytes
+// long, into a record of type typ and writes it, first replacing the key if it
+// is about to reach its limit. c.out.mu must be held.
+func (c *Conn) writeRecord(typ byte, payload []byte) error {
+	if c.out.err != nil {
+		return c.out.err
+	}
+	if typ != recordTypeKeyUpdate && c.out.seq+1 >= c.recordsPerKey() {
+		// The key update is the last record under the current key.
+		if err := c.writeRecord(recordTypeKeyUpdate, nil); err != nil {
+			return err
+		}
+		c.out.update()
+	}
+
+	c.outBuf = append(c.outBuf[:0], typ, 0, 0)
+	binary.BigEndian.PutUint16(c.outBuf[1:], uint16(len(payload)+16))
+	nonce := c.out.nonce()
+	c.outBuf = c.out.aead.Seal(c.outBuf, nonce[:], payload, c.outBuf[:recordHeaderSize])
+	c.out.seq++
+
+	if _, err := c.conn.Write(c.outBuf); err != nil {
+		c.out.err = err
+		return err
+	}
+	return nil
+}
+
+// readRecord reads and opens the next record. The payload it returns is
+// only valid until the next call. c.in.mu must be held.
+func (c *Conn) readRecord() (byte, []byte, error) {
+	if c.in.err != nil {
+		return 0, nil, c.in.err
+	}
+	typ, payload, err := c.readRecordRaw()
+	if err != nil {
+		c.in.err = err
+	}
+	return typ, payload, err
+}
+
+func (c *Conn) readRecordRaw() (byte, []byte, error) {
+	header := c.raw[:recordHeaderSize]
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	typ, n := header[0], int(binary.BigEndian.Uint16(header[1:]))
+	if n < 16 || n > maxRecordPayload+16 {
+		return 0, nil, errConnRecord
+	}
+	body := c.raw[recordHeaderSize : recordHeaderSize+n]
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+
+	nonce := c.in.nonce()
+	payload, err := c.in.aead.Open(body[:0], nonce[:], body, header)
+	if err != nil {
+		return 0, nil, err
+	}
+	c.in.seq++
+	return typ, payload, nil
+}
+
+// Read reads data from the connection. It returns io.EOF once the peer has
+// sent close_notify, and io.ErrUnexpectedEOF if the underlying connection
+// ends before that.
+func (c *Conn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	c.in.mu.Lock()
+	defer c.in.mu.Unlock()
+
+	for len(c.input) == 0 {
+		typ, payload, err := c.readRecord()
+		if err != nil {
+			return 0, err
+		}
+		switch typ {
+		case recordTypeData:
+			c.input = payload
+		case recordTypeKeyUpdate:
+			if len(payload) != 0 {
+				c.in.err = errConnRecord
+				return 0, c.in.err
+			}
+			c.in.update()
+		case recordTypeCloseNotify:
+			c.in.err = io.EOF
+			return 0, c.in.err
+		default:
+			c.in.err = errConnRecord
+			return 0, c.in.err
+		}
+	}
+
+	n := copy(b, c.input)
+	c.input = c.input[n:]
+	return n, nil
+}
+
+// Write writes data to the connection, splitting it into records.
+func (c *Conn) Write(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+
+	if c.closeNotifySent {
+		return 0, errConnClosed
+	}
+	var n int
+	for len(b) > 0 {
+		chunk := b[:min(len(b), maxRecordPayload)]
+		if err := c.writeRecord(recordTypeData, chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+// CloseWrite sends close_notify without closing the underlying connection,
+// after which the peer's Read returns io.EOF. Further writes return an
+// error. The handshake must have completed.
+func (c *Conn) CloseWrite() error {
+	if !c.handshakeComplete.Load() {
+		return errConnNotComplete
+	}
+	return c.closeNotify()
+}
+
+func (c *Conn) closeNotify() error {
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+	return c.closeNotifyLocked()
+}
+
+// closeNotifyLocked sends close_notify, if it has not been sent yet. c.out.mu
+// must be held.
+func (c *Conn) closeNotifyLocked() error {
+	if c.closeNotifySent {
+		return nil
+	}
+	c.closeNotifySent = true
+	// Don't block forever on a peer that has stopped reading.
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	err := c.writeRecord(recordTypeCloseNotify, nil)
+	c.conn.SetWriteDeadline(time.Time{})
+	return err
+}
+
+// Close sends close_notify, if the handshake has completed, and closes the
+// underlying connection. If a Write is in progress, which may be blocked on a
+// peer that has stopped reading, Close does not wait for it: it closes the
+// underlying connection without sending close_notify, which makes the Write
+// return an error.
+func (c *Conn) Close() error {
+	var notifyErr error
+	if c.handshakeComplete.Load() && c.out.mu.TryLock() {
+		notifyErr = c.closeNotifyLocked()
+		c.out.mu.Unlock()
+	}
+	if err := c.conn.Close(); err != nil {
+		return err
+	}
+	return notifyErr
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the underlying
+// connection. A Write that times out may leave the connection unusable.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection. A
+// Read that times out may leave the connection unusable.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection. A
+// Write that times out may leave the connection unusable.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
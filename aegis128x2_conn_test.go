@@ -0,0 +1,261 @@
+package aegis_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/balasanjay/aegis"
+)
+
+// exchange writes toServer and toClient concurrently through client and
+// server, closing each for writing when done, and returns what each side read.
+func exchange(t *testing.T, client, server *aegis.Conn, toServer, toClient []byte) (fromClient, fromServer []byte) {
+	t.Helper()
+	errc := make(chan error, 2)
+	send := func(c *aegis.Conn, b []byte) {
+		if _, err := c.Write(b); err != nil {
+			errc <- err
+			return
+		}
+		errc <- c.CloseWrite()
+	}
+	go send(client, toServer)
+	go send(server, toClient)
+
+	readc := make(chan []byte)
+	go func() {
+		b, err := io.ReadAll(server)
+		if err != nil {
+			t.Errorf("server read: %v", err)
+		}
+		readc <- b
+	}()
+	fromServer, err := io.ReadAll(client)
+	if err != nil {
+		t.Errorf("client read: %v", err)
+	}
+	fromClient = <-readc
+	for range 2 {
+		if err := <-errc; err != nil {
+			t.Errorf("write: %v", err)
+		}
+	}
+	return fromClient, fromServer
+}
+
+func TestConn(t *testing.T) {
+	rng := rand.New(rand.NewPCG(61, 62))
+	psk := randomBytes(rng, 32)
+
+	for _, recordsPerKey := range []uint64{0, 2, 3, 10} {
+		for _, length := range []int{0, 1, 16<<10 - 1, 16 << 10, 16<<10 + 1, 100 << 10} {
+			config := &aegis.Config{PSK: psk, RecordsPerKey: recordsPerKey}
+			c1, c2 := net.Pipe()
+			client, server := aegis.Client(c1, config), aegis.Server(c2, config)
+
+			toServer, toClient := randomBytes(rng, length), randomBytes(rng, length/3)
+			fromClient, fromServer := exchange(t, client, server, toServer, toClient)
+			if !bytes.Equal(fromClient, toServer) || !bytes.Equal(fromServer, toClient) {
+				t.Errorf("RecordsPerKey=%d len=%d: data was not exchanged intact", recordsPerKey, length)
+			}
+
+			if _, err := client.Write([]byte{1}); err == nil {
+				t.Errorf("RecordsPerKey=%d len=%d: Write after CloseWrite succeeded", recordsPerKey, length)
+			}
+			client.Close()
+			server.Close()
+		}
+	}
+}
+
+func TestConnWrongPSK(t *testing.T) {
+	rng := rand.New(rand.NewPCG(63, 64))
+	c1, c2 := net.Pipe()
+	client := aegis.Client(c1, &aegis.Config{PSK: randomBytes(rng, 16)})
+	server := aegis.Server(c2, &aegis.Config{PSK: randomBytes(rng, 16)})
+
+	errc := make(chan error)
+	go func() {
+		err := server.Handshake()
+		c2.Close()
+		errc <- err
+	}()
+	if err := client.Handshake(); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("client Handshake() = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+	c1.Close()
+	if err := <-errc; err == nil {
+		t.Errorf("server Handshake() succeeded")
+	}
+	if _, err := client.Write([]byte{1}); err == nil {
+		t.Errorf("Write after failed handshake succeeded")
+	}
+}
+
+func TestConnConfig(t *testing.T) {
+	for _, config := range []*aegis.Config{
+		nil,
+		{PSK: make([]byte, 15)},
+		{PSK: make([]byte, 16), RecordsPerKey: 1},
+	} {
+		c1, c2 := net.Pipe()
+		if err := aegis.Client(c1, config).Handshake(); err == nil {
+			t.Errorf("Handshake() with %+v succeeded", config)
+		}
+		c1.Close()
+		c2.Close()
+	}
+}
+
+func TestConnTruncation(t *testing.T) {
+	rng := rand.New(rand.NewPCG(65, 66))
+	config := &aegis.Config{PSK: randomBytes(rng, 16)}
+	c1, c2 := net.Pipe()
+	client, server := aegis.Client(c1, config), aegis.Server(c2, config)
+
+	go func() {
+		server.Write([]byte("partial"))
+		// Close the underlying connection without sending close_notify.
+		c2.Close()
+	}()
+	got, err := io.ReadAll(client)
+	if string(got) != "partial" || err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadAll() = %q, %v; want %q, %v", got, err, "partial", io.ErrUnexpectedEOF)
+	}
+	client.Close()
+}
+
+// writeSignalConn closes started when Write is first called on it after
+// armed is set.
+type writeSignalConn struct {
+	net.Conn
+	armed   atomic.Bool
+	once    sync.Once
+	started chan struct{}
+}
+
+func (c *writeSignalConn) Write(b []byte) (int, error) {
+	if c.armed.Load() {
+		c.once.Do(func() { close(c.started) })
+	}
+	return c.Conn.Write(b)
+}
+
+func TestConnCloseDuringWrite(t *testing.T) {
+	rng := rand.New(rand.NewPCG(69, 70))
+	config := &aegis.Config{PSK: randomBytes(rng, 16)}
+	c1, c2 := net.Pipe()
+	raw := &writeSignalConn{Conn: c1, started: make(chan struct{})}
+	client, server := aegis.Client(raw, config), aegis.Server(c2, config)
+	defer server.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- server.Handshake() }()
+	if err := client.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// The server never reads, so this Write blocks until Close stops it.
+	raw.armed.Store(true)
+	go func() {
+		_, err := client.Write(make([]byte, 100))
+		errc <- err
+	}()
+	<-raw.started
+
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked behind a Write to a peer that does not read")
+	}
+	if err := <-errc; err == nil {
+		t.Error("Write interrupted by Close succeeded")
+	}
+}
+
+// tamperConn applies tamper to the data passed to the n-th call to Write.
+type tamperConn struct {
+	net.Conn
+	n      int
+	tamper func(c net.Conn, b []byte) error
+}
+
+func (c *tamperConn) Write(b []byte) (int, error) {
+	c.n--
+	if c.n != -1 {
+		return c.Conn.Write(b)
+	}
+	return len(b), c.tamper(c.Conn, b)
+}
+
+func TestConnTampering(t *testing.T) {
+	rng := rand.New(rand.NewPCG(67, 68))
+	config := &aegis.Config{PSK: randomBytes(rng, 16), RecordsPerKey: 3}
+
+	tests := []struct {
+		name   string
+		tamper func(c net.Conn, b []byte) error
+	}{
+		{"flip header", func(c net.Conn, b []byte) error {
+			_, err := c.Write(bytesWithFlippedBit(b, 0))
+			return err
+		}},
+		{"flip body", func(c net.Conn, b []byte) error {
+			_, err := c.Write(bytesWithFlippedBit(b, len(b)/2))
+			return err
+		}},
+		{"flip tag", func(c net.Conn, b []byte) error {
+			_, err := c.Write(bytesWithFlippedBit(b, len(b)-1))
+			return err
+		}},
+		{"drop", func(c net.Conn, b []byte) error {
+			return nil
+		}},
+		{"replay", func(c net.Conn, b []byte) error {
+			if _, err := c.Write(b); err != nil {
+				return err
+			}
+			_, err := c.Write(b)
+			return err
+		}},
+	}
+	// The client's first two writes are its hello and Finished record; the
+	// data records that follow include key updates every other record.
+	for _, tt := range tests {
+		for n := 2; n < 7; n++ {
+			c1, c2 := net.Pipe()
+			client := aegis.Client(&tamperConn{c1, n, tt.tamper}, config)
+			server := aegis.Server(c2, config)
+
+			go func() {
+				for i := range 5 {
+					if _, err := client.Write([]byte{byte(i)}); err != nil {
+						break
+					}
+				}
+				client.CloseWrite()
+			}()
+			_, err := io.ReadAll(server)
+			if err == nil {
+				t.Errorf("%s record %d: ReadAll() succeeded", tt.name, n)
+			}
+			c2.Close()
+			c1.Close()
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package aegis
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// File128x2 is a random-access encrypted file, stored in an underlying
+// io.ReaderAt and io.WriterAt. The plaintext is cut into blocks of
+// FileBlockSize bytes, each sealed on its own with AEAD128x2, so that any range
+// can be read or written by only touching the blocks it covers.
+//
+// The underlying storage holds a 64-byte header followed by the blocks:
+//
+//	header: "aegisra" || version || nonce || Seal(fileID || size)
+//	block:  nonce || Seal(plaintext) || tag
+//
+// Nonces are random, and drawn again every time a block or the header is
+// written. Every block is sealed with the file ID and its own index as
+// additional data, so that blocks moved within the file, or taken from another
+// file under the same key, are detected. The last block is also sealed with
+// the size of the file, and OpenFile checks it against the size in the header,
+// so that blocks dropped from the end of the file, or an older header put back
+// in front of it, are detected. A file always has a last block, which is empty
+// if the file is.
+//
+// File128x2 does not protect against rolling a whole file, or single blocks,
+// back to earlier versions, and is not crash-safe: a write interrupted halfway
+// may leave the file unreadable.
+type File128x2 struct {
+	a  AEAD128x2
+	r  io.ReaderAt
+	w  io.WriterAt // nil if read-only
+	id [16]byte
+
+	mu   sync.RWMutex
+	size int64
+	off  int64 // for Read, Write and Seek
+}
+
+const (
+	// FileBlockSize is the size of the plaintext of every block of a
+	// File128x2 but the last.
+	FileBlockSize = 4096
+
+	fileMagic         = "aegisra"
+	fileVersion       = 2
+	fileHeaderSize    = len(fileMagic) + 1 + 16 + 16 + 8 + 16
+	fileBlockOverhead = 16 + 16
+)
+
+var (
+	errFileInvalid  = errors.New("aegis: not an encrypted file")
+	errFileVersion  = errors.New("aegis: unsupported encrypted file version")
+	errFileReadOnly = errors.New("aegis: encrypted file is read-only")
+	errFileOffset   = errors.New("aegis: negative offset")
+	errFileWhence   = errors.New("aegis: invalid whence")
+)
+
+var (
+	_ io.ReaderAt        = (*File128x2)(nil)
+	_ io.WriterAt        = (*File128x2)(nil)
+	_ io.ReadWriteSeeker = (*File128x2)(nil)
+)
+
+// CreateFile starts a new, empty encrypted file in rw, overwriting its
+// header and first block.
+func (a AEAD128x2) CreateFile(rw interface {
+	io.ReaderAt
+	io.WriterAt
+}) (*File128x2, error) {
+	f := &File128x2{a: a, r: rw, w: rw}
+	rand.Read(f.id[:])
+	if err := f.writeBlock(new([FileBlockSize + fileBlockOverhead]byte), 0, 0); err != nil {
+		return nil, err
+	}
+	if err := f.writeHeader(0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// OpenFile opens the encrypted file stored in r. It can be written to if r
+// also implements io.WriterAt. It returns ErrAuthFailed if the header or the
+// last block does not authenticate under the key, or they disagree on the
+// size of the file.
+func (a AEAD128x2) OpenFile(r io.ReaderAt) (*File128x2, error) {
+	var header [fileHeaderSize]byte
+	if n, err := r.ReadAt(header[:], 0); n < len(header) {
+		if err == nil || err == io.EOF {
+			err = errFileInvalid
+		}
+		return nil, err
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, errFileInvalid
+	}
+	if header[len(fileMagic)] != fileVersion {
+		return nil, errFileVersion
+	}
+
+	aad := header[:len(fileMagic)+1]
+	nonce := header[len(aad) : len(aad)+16]
+	body, err := a.Open(nil, nonce, header[len(aad)+16:], aad)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File128x2{a: a, r: r}
+	f.w, _ = r.(io.WriterAt)
+	copy(f.id[:], body[:16])
+	f.size = int64(binary.BigEndian.Uint64(body[16:]))
+	if f.size < 0 {
+		return nil, ErrAuthFailed
+	}
+
+	last := lastBlock(f.size)
+	if _, err := f.readBlock(new([FileBlockSize + fileBlockOverhead]byte), last, f.size); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// writeHeader seals and writes the header of a file of the given size.
+func (f *File128x2) writeHeader(size int64) error {
+	var header [fileHeaderSize]byte
+	aad := append(header[:0], fileMagic...)
+	aad = append(aad, fileVersion)
+	nonce := header[len(aad) : len(aad)+16]
+	rand.Read(nonce)
+
+	var body [24]byte
+	copy(body[:], f.id[:])
+	binary.BigEndian.PutUint64(body[16:], uint64(size))
+	f.a.Seal(header[len(aad)+16:len(aad)+16], nonce, body[:], aad)
+
+	_, err := f.w.WriteAt(header[:], 0)
+	return err
+}
+
+// Size returns the size of the plaintext.
+func (f *File128x2) Size() int64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.size
+}
+
+// blockLen returns the length of the plaintext of block i, in a file of the
+// given size.
+func blockLen(i, size int64) int {
+	return int(min(FileBlockSize, size-i*FileBlockSize))
+}
+
+// lastBlock returns the index of the last block of a file of the given size.
+func lastBlock(size int64) int64 {
+	if size == 0 {
+		return 0
+	}
+	return (size - 1) / FileBlockSize
+}
+
+// blockAAD returns the additional data of block i, in a file of the given
+// size: the file ID and the index, followed by the size for the last block.
+func (f *File128x2) blockAAD(aad *[32]byte, i, size int64) []byte {
+	copy(aad[:], f.id[:])
+	binary.BigEndian.PutUint64(aad[16:], uint64(i))
+	if i != lastBlock(size) {
+		return aad[:24]
+	}
+	binary.BigEndian.PutUint64(aad[24:], uint64(size))
+	return aad[:]
+}
+
+func blockOffset(i int64) int64 {
+	return int64(fileHeaderSize) + i*(FileBlockSize+fileBlockOverhead)
+}
+
+// readBlock reads and opens block i of a file of the given size into buf, and
+// returns its plaintext, which is buf[16:16+blockLen(i, size)].
+func (f *File128x2) readBlock(buf *[FileBlockSize + fileBlockOverhead]byte, i, size int64) ([]byte, error) {
+	stored := buf[:blockLen(i, size)+fileBlockOverhead]
+	if m, err := f.r.ReadAt(stored, blockOffset(i)); m < len(stored) {
+		// The block was cut short, or is missing.
+		if err == nil || err == io.EOF {
+			err = ErrAuthFailed
+		}
+		return nil, err
+	}
+
+	var aad [32]byte
+	return f.a.Open(stored[16:16], stored[:16], stored[16:], f.blockAAD(&aad, i, size))
+}
+
+// writeBlock seals and writes block i of a file of the given size, whose
+// plaintext is buf[16:16+blockLen(i, size)].
+func (f *File128x2) writeBlock(buf *[FileBlockSize + fileBlockOverhead]byte, i, size int64) error {
+	rand.Read(buf[:16])
+	var aad [32]byte
+	n := blockLen(i, size)
+	sealed := f.a.Seal(buf[16:16], buf[:16], buf[16:16+n], f.blockAAD(&aad, i, size))
+	_, err := f.w.WriteAt(buf[:16+len(sealed)], blockOffset(i))
+	return err
+}
+
+// ReadAt reads len(p) bytes of plaintext starting at off. It returns
+// ErrAuthFailed if any block it reads was modified, moved or cut short.
+func (f *File128x2) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.readAt(p, off)
+}
+
+func (f *File128x2) readAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errFileOffset
+	}
+	if off >= f.size {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	end := min(off+int64(len(p)), f.size)
+	buf := new([FileBlockSize + fileBlockOverhead]byte)
+	n := 0
+	for pos := off; pos < end; {
+		i := pos / FileBlockSize
+		plaintext, err := f.readBlock(buf, i, f.size)
+		if err != nil {
+			return n, err
+		}
+		k := copy(p[n:end-off], plaintext[pos-i*FileBlockSize:])
+		n += k
+		pos += int64(k)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt writes p as plaintext starting at off, growing the file if needed.
+// Writing past the end of the file fills the gap with zeros. If it fails
+// partway, it returns the number of bytes of p that were written.
+func (f *File128x2) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writeAt(p, off)
+}
+
+func (f *File128x2) writeAt(p []byte, off int64) (int, error) {
+	if f.w == nil {
+		return 0, errFileReadOnly
+	}
+	if off < 0 {
+		return 0, errFileOffset
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	end := off + int64(len(p))
+	newSize := max(f.size, end)
+	buf := new([FileBlockSize + fileBlockOverhead]byte)
+
+	// Rewrite every block from the one holding the old end of the file, if
+	// off is past it, so that the gap is zero-filled. If the file grows, its
+	// old last block is rewritten too, since its additional data changes.
+	first := min(off, f.size) / FileBlockSize
+	if newSize > f.size {
+		first = min(first, lastBlock(f.size))
+	}
+	written := 0
+	for i := first; i*FileBlockSize < end; i++ {
+		start := i * FileBlockSize
+		n := blockLen(i, newSize)
+
+		// Keep the old contents of the block, unless they are all
+		// overwritten, and zero-fill what lies past them.
+		block := buf[16 : 16+n]
+		kept := 0
+		if start < f.size && (off > start || end < start+int64(n)) {
+			old, err := f.readBlock(buf, i, f.size)
+			if err != nil {
+				return written, err
+			}
+			kept = len(old)
+		}
+		clear(block[kept:])
+
+		lo, hi := max(off, start), min(end, start+int64(n))
+		if lo < hi {
+			copy(block[lo-start:], p[lo-off:hi-off])
+		}
+		if err := f.writeBlock(buf, i, newSize); err != nil {
+			return written, err
+		}
+		if lo < hi {
+			written = int(hi - off)
+		}
+	}
+
+	if newSize > f.size {
+		if err := f.writeHeader(newSize); err != nil {
+			// Only the bytes within the old size are covered by the header
+			// on disk.
+			return int(min(max(f.size-off, 0), int64(written))), err
+		}
+		f.size = newSize
+	}
+	return len(p), nil
+}
+
+// Read reads plaintext from the current offset, and advances it.
+func (f *File128x2) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.readAt(p, f.off)
+	f.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Write writes plaintext at the current offset, and advances it.
+func (f *File128x2) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.writeAt(p, f.off)
+	f.off += int64(n)
+	return n, err
+}
+
+// Seek sets the offset of the next Read or Write, relative to whence, as
+// io.Seeker describes. Offsets are in the plaintext.
+func (f *File128x2) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errFileWhence
+	}
+	if offset < 0 {
+		return 0, errFileOffset
+	}
+	f.off = offset
+	return offset, nil
+}
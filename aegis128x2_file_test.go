@@ -0,0 +1,273 @@
+package aegis_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// memFile is an in-memory io.ReaderAt and io.WriterAt.
+type memFile struct {
+	b []byte
+}
+
+func (m *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.b) {
+		m.b = append(m.b, make([]byte, end-len(m.b))...)
+	}
+	return copy(m.b[off:], p), nil
+}
+
+func TestAegis128x2File(t *testing.T) {
+	const bs = aegis.FileBlockSize
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(47, 48))
+		aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+		storage := new(memFile)
+		f, err := aead.CreateFile(storage)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Apply random writes, including past the end, to the file and to
+		// a plain model of it, and check random reads against the model.
+		var model []byte
+		for range 100 {
+			off := rng.IntN(len(model) + bs + 10)
+			p := randomBytes(rng, rng.IntN(3*bs))
+			if n, err := f.WriteAt(p, int64(off)); n != len(p) || err != nil {
+				t.Fatalf("WriteAt(%d bytes, %d) = %d, %v", len(p), off, n, err)
+			}
+			if end := off + len(p); end > len(model) {
+				model = append(model, make([]byte, end-len(model))...)
+			}
+			copy(model[off:], p)
+
+			if f.Size() != int64(len(model)) {
+				t.Fatalf("Size() = %d, want %d", f.Size(), len(model))
+			}
+
+			off = rng.IntN(len(model) + 1)
+			got := make([]byte, rng.IntN(2*bs))
+			n, err := f.ReadAt(got, int64(off))
+			want := model[off:min(len(model), off+len(got))]
+			if !bytes.Equal(got[:n], want) {
+				t.Fatalf("ReadAt(%d bytes, %d) = %x, want %x", len(got), off, got[:n], want)
+			}
+			if (n < len(got)) != (err == io.EOF) || (err != nil && err != io.EOF) {
+				t.Fatalf("ReadAt(%d bytes, %d) = %d, %v", len(got), off, n, err)
+			}
+		}
+
+		// Reopen the file and read it sequentially.
+		f, err = aead.OpenFile(storage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(f)
+		if err != nil || !bytes.Equal(got, model) {
+			t.Fatalf("ReadAll(reopened file) = %d bytes, %v; want %d bytes", len(got), err, len(model))
+		}
+	})
+}
+
+func TestAegis128x2FileSeek(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{2})
+	f, err := aead.CreateFile(new(memFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "hello, world"); err != nil {
+		t.Fatal(err)
+	}
+	if off, err := f.Seek(-5, io.SeekEnd); off != 7 || err != nil {
+		t.Fatalf("Seek(-5, SeekEnd) = %d, %v", off, err)
+	}
+	if _, err := io.WriteString(f, "there"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(f); string(got) != "hello, there" || err != nil {
+		t.Fatalf("ReadAll() = %q, %v", got, err)
+	}
+	if _, err := f.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek(-1, SeekStart) succeeded")
+	}
+}
+
+func TestAegis128x2FileTampering(t *testing.T) {
+	const bs = aegis.FileBlockSize
+	const stored = bs + 32
+	const header = 64
+
+	rng := rand.New(rand.NewPCG(49, 50))
+	key := ([16]byte)(randomBytes(rng, 16))
+	aead := aegis.NewAEAD128x2(key)
+	plaintext := randomBytes(rng, 3*bs+100)
+
+	create := func() *memFile {
+		m := new(memFile)
+		f, err := aead.CreateFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteAt(plaintext, 0); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	original := create().b
+	other := create().b
+
+	tcs := []struct {
+		name   string
+		tamper func(b []byte) []byte
+	}{
+		{"SwappedBlocks", func(b []byte) []byte {
+			b0 := bytes.Clone(b[header : header+stored])
+			copy(b[header:], b[header+stored:header+2*stored])
+			copy(b[header+stored:], b0)
+			return b
+		}},
+		{"BlockFromOtherFile", func(b []byte) []byte {
+			copy(b[header+stored:], other[header+stored:header+2*stored])
+			return b
+		}},
+		{"TruncatedAtBlock", func(b []byte) []byte { return b[:header+3*stored] }},
+		{"TruncatedInBlock", func(b []byte) []byte { return b[:len(b)-1] }},
+		{"FlippedBit", func(b []byte) []byte { return bytesWithFlippedBit(b, header+stored+100) }},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			// Damage to the last block is caught by OpenFile, and to any
+			// other block when it is read.
+			f, err := aead.OpenFile(&memFile{tc.tamper(bytes.Clone(original))})
+			if err == nil {
+				_, err = io.ReadAll(f)
+			}
+			if !errors.Is(err, aegis.ErrAuthFailed) {
+				t.Errorf("OpenFile() or ReadAll() = %v, want %v", err, aegis.ErrAuthFailed)
+			}
+		})
+	}
+
+	// A header that does not authenticate, including under the wrong key,
+	// cannot be opened.
+	if _, err := aead.OpenFile(&memFile{bytesWithFlippedBit(original, 40)}); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("OpenFile(modified header) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+	if _, err := aegis.NewAEAD128x2([16]byte{}).OpenFile(&memFile{original}); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("OpenFile(wrong key) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+	if _, err := aead.OpenFile(&memFile{original[:10]}); err == nil {
+		t.Error("OpenFile(truncated header) succeeded")
+	}
+}
+
+func TestAegis128x2FileReadOnly(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{3})
+	m := new(memFile)
+	f, err := aead.CreateFile(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte("data"), 0)
+
+	ro, err := aead.OpenFile(bytes.NewReader(m.b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ro.WriteAt([]byte("x"), 0); err == nil {
+		t.Error("WriteAt on a read-only file succeeded")
+	}
+	got := make([]byte, 4)
+	if _, err := ro.ReadAt(got, 0); err != nil || string(got) != "data" {
+		t.Errorf("ReadAt() = %q, %v", got, err)
+	}
+}
+
+func TestAegis128x2FileOldHeader(t *testing.T) {
+	const bs = aegis.FileBlockSize
+	const header = 64
+
+	aead := aegis.NewAEAD128x2([16]byte{4})
+	for _, size := range []int{0, 100, bs, 2 * bs} {
+		m := new(memFile)
+		f, err := aead.CreateFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteAt(make([]byte, size), 0); err != nil {
+			t.Fatal(err)
+		}
+		oldHeader := bytes.Clone(m.b[:header])
+
+		// Growing the file, even by a byte, must not let the old header,
+		// which claims the old size, be put back.
+		for _, grow := range []int{1, bs} {
+			if _, err := f.WriteAt(make([]byte, grow), f.Size()); err != nil {
+				t.Fatal(err)
+			}
+			replayed := append(bytes.Clone(oldHeader), m.b[header:]...)
+			if _, err := aead.OpenFile(&memFile{replayed}); !errors.Is(err, aegis.ErrAuthFailed) {
+				t.Errorf("size=%d: OpenFile(header of the file before it grew by %d) = %v, want %v", size, grow, err, aegis.ErrAuthFailed)
+			}
+		}
+	}
+}
+
+// failingFile is a memFile whose writes past limit bytes fail, if limit is
+// set.
+type failingFile struct {
+	memFile
+	limit int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (m *failingFile) WriteAt(p []byte, off int64) (int, error) {
+	if m.limit > 0 && int(off)+len(p) > m.limit {
+		return 0, errWriteFailed
+	}
+	return m.memFile.WriteAt(p, off)
+}
+
+func TestAegis128x2FilePartialWrite(t *testing.T) {
+	const bs = aegis.FileBlockSize
+	const stored = bs + 32
+	const header = 64
+
+	aead := aegis.NewAEAD128x2([16]byte{5})
+	m := new(failingFile)
+	f, err := aead.CreateFile(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(make([]byte, 3*bs), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two blocks are written, and the third fails.
+	m.limit = header + 2*stored
+	n, err := f.WriteAt(make([]byte, 3*bs-10), 10)
+	if n != 2*bs-10 || !errors.Is(err, errWriteFailed) {
+		t.Errorf("WriteAt() = %d, %v; want %d, %v", n, err, 2*bs-10, errWriteFailed)
+	}
+}
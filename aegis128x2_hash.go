@@ -0,0 +1,114 @@
+package aegis
+
+import (
+	"crypto/subtle"
+	"hash"
+)
+
+// Hash128x2 computes an AEGIS-128X2 MAC incrementally, producing the same tag
+// as Mac128x2.Sum16 or Mac128x2.Sum32 over everything written to it.
+type Hash128x2 struct {
+	key, nonce [16]byte
+	tagSize    int
+
+	state state128x2
+
+	// buf holds the start of an incomplete 64-byte block, and n its length.
+	buf [64]byte
+	n   int
+
+	len uint64
+}
+
+var _ hash.Cloner = (*Hash128x2)(nil)
+
+// NewHash returns a Hash128x2 for the given nonce, which must be 16 bytes long,
+// whose Sum appends a tag of tagSize bytes. tagSize must be 16 or 32.
+func (m Mac128x2) NewHash(nonce []byte, tagSize int) (*Hash128x2, error) {
+	if len(nonce) != 16 {
+		return nil, ErrInvalidNonceSize
+	}
+	if tagSize != 16 && tagSize != 32 {
+		return nil, ErrInvalidTagSize
+	}
+	h := &Hash128x2{key: m.key, nonce: [16]byte(nonce), tagSize: tagSize}
+	h.Reset()
+	return h, nil
+}
+
+// Write absorbs p. It never returns an error, and panics with
+// ErrMessageTooLong if the data grows past what AEGIS allows.
+func (h *Hash128x2) Write(p []byte) (int, error) {
+	if err := checkLengths(0, h.len+uint64(len(p))); err != nil {
+		panic(err)
+	}
+	h.len += uint64(len(p))
+	n := len(p)
+
+	if h.n > 0 {
+		k := copy(h.buf[h.n:], p)
+		h.n += k
+		p = p[k:]
+		if h.n < 64 {
+			return n, nil
+		}
+		h.state.absorb(h.buf[:])
+		h.n = 0
+	}
+
+	full := len(p) &^ 63
+	h.state.absorb(p[:full])
+	h.n = copy(h.buf[:], p[full:])
+	return n, nil
+}
+
+// Sum appends the tag of the data written so far to b. It does not change the
+// underlying state, so more data may be written afterwards.
+func (h *Hash128x2) Sum(b []byte) []byte {
+	state := h.state
+	absorbAad(&state, h.buf[:h.n])
+
+	if h.tagSize == 32 {
+		tag := state.finalizeMac32(h.len)
+		return append(b, tag[:]...)
+	}
+	tag := state.finalizeMac16(h.len)
+	return append(b, tag[:]...)
+}
+
+// Verify checks that tag is the tag of the data written so far, in constant
+// time, and returns ErrAuthFailed if it is not. A tag of the wrong size never
+// matches. Like Sum, it does not change the underlying state.
+func (h *Hash128x2) Verify(tag []byte) error {
+	var buf [32]byte
+	expectedTag := h.Sum(buf[:0])
+	if subtle.ConstantTimeCompare(expectedTag, tag) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// Reset discards the data written so far, keeping the key and nonce.
+func (h *Hash128x2) Reset() {
+	h.state.init(&h.key, &h.nonce)
+	clear(h.buf[:])
+	h.n = 0
+	h.len = 0
+}
+
+// Size returns the tag size, 16 or 32 bytes.
+func (h *Hash128x2) Size() int {
+	return h.tagSize
+}
+
+// BlockSize returns 64, the number of bytes AEGIS-128X2 absorbs at once.
+func (h *Hash128x2) BlockSize() int {
+	return 64
+}
+
+// Clone returns an independent copy of h, which can be used to compute tags of
+// several messages sharing a prefix. It never returns an error.
+func (h *Hash128x2) Clone() (hash.Cloner, error) {
+	clone := *h
+	return &clone, nil
+}
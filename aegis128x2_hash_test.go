@@ -0,0 +1,143 @@
+package aegis_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestAegisMac128x2Hash(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(13, 14))
+		for _, length := range []int{0, 1, 63, 64, 65, 127, 128, 200, 1000} {
+			key := ([16]byte)(randomBytes(rng, 16))
+			nonce := randomBytes(rng, 16)
+			data := randomBytes(rng, length)
+
+			mac := aegis.NewMac128x2(key)
+			want16 := mac.Sum16(nonce, data)
+			want32 := mac.Sum32(nonce, data)
+
+			for _, tagSize := range []int{16, 32} {
+				h, err := mac.NewHash(nonce, tagSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if h.Size() != tagSize || h.BlockSize() != 64 {
+					t.Fatalf("Size() = %d, BlockSize() = %d", h.Size(), h.BlockSize())
+				}
+				want := want16[:]
+				if tagSize == 32 {
+					want = want32[:]
+				}
+
+				writeInChunks(rng, data, func(chunk []byte) { h.Write(chunk) })
+				if got := h.Sum(nil); !bytes.Equal(got, want) {
+					t.Fatalf("len=%d: Sum() = %x, want %x", length, got, want)
+				}
+				// Sum must not disturb the state.
+				if got := h.Sum([]byte("prefix")); !bytes.Equal(got, append([]byte("prefix"), want...)) {
+					t.Fatalf("len=%d: second Sum() = %x, want prefix||%x", length, got, want)
+				}
+
+				h.Reset()
+				if _, err := io.Copy(h, bytes.NewReader(data)); err != nil {
+					t.Fatal(err)
+				}
+				if got := h.Sum(nil); !bytes.Equal(got, want) {
+					t.Fatalf("len=%d: Sum() after Reset = %x, want %x", length, got, want)
+				}
+			}
+		}
+	})
+}
+
+func TestAegisMac128x2HashVector(t *testing.T) {
+	mac := aegis.NewMac128x2(([16]byte)(unhex("10010000000000000000000000000000")))
+	for _, tc := range []struct {
+		tagSize int
+		want    string
+	}{
+		{16, "6873ee34e6b5c59143b6d35c5e4f2c6e"},
+		{32, "afcba3fc2d63c8d6c7f2d63f3ec8fbbbaf022e15ac120e78ffa7755abccd959c"},
+	} {
+		h, err := mac.NewHash(unhex("10000200000000000000000000000000"), tc.tagSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range unhex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122") {
+			h.Write([]byte{b})
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != tc.want {
+			t.Errorf("got tag%d=%q, want tag=%q", tc.tagSize, got, tc.want)
+		}
+	}
+}
+
+func TestAegisMac128x2HashClone(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	mac := aegis.NewMac128x2(([16]byte)(randomBytes(rng, 16)))
+	nonce := randomBytes(rng, 16)
+	prefix := randomBytes(rng, 100)
+	suffixA := randomBytes(rng, 30)
+	suffixB := randomBytes(rng, 70)
+
+	h, err := mac.NewHash(nonce, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write(prefix)
+	clone, err := h.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write(suffixA)
+	clone.Write(suffixB)
+
+	wantA := mac.Sum16(nonce, append(bytes.Clone(prefix), suffixA...))
+	wantB := mac.Sum16(nonce, append(bytes.Clone(prefix), suffixB...))
+	if got := h.Sum(nil); !bytes.Equal(got, wantA[:]) {
+		t.Errorf("original Sum() = %x, want %x", got, wantA)
+	}
+	if got := clone.Sum(nil); !bytes.Equal(got, wantB[:]) {
+		t.Errorf("clone Sum() = %x, want %x", got, wantB)
+	}
+}
+
+func TestAegisMac128x2HashErrors(t *testing.T) {
+	mac := aegis.NewMac128x2([16]byte{})
+	if _, err := mac.NewHash(make([]byte, 12), 16); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("NewHash(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, err := mac.NewHash(make([]byte, 16), 24); !errors.Is(err, aegis.ErrInvalidTagSize) {
+		t.Errorf("NewHash(tagSize=24) = %v, want %v", err, aegis.ErrInvalidTagSize)
+	}
+}
+
+func TestAegisMac128x2HashVerify(t *testing.T) {
+	rng := rand.New(rand.NewPCG(19, 20))
+	mac := aegis.NewMac128x2(([16]byte)(randomBytes(rng, 16)))
+	nonce := randomBytes(rng, 16)
+	data := randomBytes(rng, 100)
+	tag := mac.Sum32(nonce, data)
+
+	h, err := mac.NewHash(nonce, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write(data)
+	if err := h.Verify(tag[:]); err != nil {
+		t.Errorf("Verify(correct tag) = %v", err)
+	}
+	if err := h.Verify(bytesWithFlippedBit(tag[:], 31)); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("Verify(modified tag) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+	if err := h.Verify(tag[:16]); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("Verify(truncated tag) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+}
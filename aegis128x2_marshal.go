@@ -0,0 +1,269 @@
+package aegis
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"errors"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+// Encoded states let a long computation be checkpointed and resumed, possibly
+// in another process. An encoded state is
+//
+//	"aegis" || kind || version || body || checksum
+//
+// where kind identifies the type that produced it, version is stateVersion,
+// and checksum is the first 16 bytes of HMAC-SHA256 over everything before
+// it. The checksum key is HMAC-SHA256(key, "aegis state checksum"), so that
+// the AEGIS key is only ever used by AEGIS itself. The key is never encoded,
+// so a state can only be decoded by a value that already holds the same key.
+//
+// The body holds the AEGIS registers, which are as sensitive as the key for
+// the rest of the message: anyone holding an encoded state can decrypt what
+// follows, or forge its tag. It also holds the start of any incomplete
+// 64-byte block in the clear, which for a Sealer or Opener is plaintext.
+
+const (
+	stateMagic        = "aegis"
+	stateVersion      = 2
+	stateHeaderSize   = len(stateMagic) + 2
+	stateChecksumSize = 16
+
+	stateKindHash128x2   = 'm'
+	stateKindSealer128x2 = 's'
+	stateKindOpener128x2 = 'o'
+)
+
+var (
+	errStateInvalid      = errors.New("aegis: invalid encoded state")
+	errStateVersion      = errors.New("aegis: unsupported encoded state version")
+	errStateChecksum     = errors.New("aegis: encoded state checksum mismatch, or wrong key")
+	errStateFinished     = errors.New("aegis: cannot encode the state of a finished Sealer or Opener")
+	errStateInconsistent = errors.New("aegis: inconsistent encoded state")
+)
+
+func appendStateHeader(b []byte, kind byte) []byte {
+	b = append(b, stateMagic...)
+	return append(b, kind, stateVersion)
+}
+
+// stateChecksumKey derives the key of encoded state checksums from the AEGIS
+// key.
+func stateChecksumKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("aegis state checksum"))
+	return mac.Sum(nil)
+}
+
+// appendStateChecksum appends the checksum of b[start:] to b.
+func appendStateChecksum(b []byte, start int, key []byte) []byte {
+	mac := hmac.New(sha256.New, stateChecksumKey(key))
+	mac.Write(b[start:])
+	return mac.Sum(b)[:len(b)+stateChecksumSize]
+}
+
+// openState checks the header and checksum of an encoded state, and returns
+// its body, which must be bodySize bytes long.
+func openState(b []byte, kind byte, bodySize int, key []byte) ([]byte, error) {
+	if len(b) < stateHeaderSize || string(b[:len(stateMagic)]) != stateMagic || b[len(stateMagic)] != kind {
+		return nil, errStateInvalid
+	}
+	if b[len(stateMagic)+1] != stateVersion {
+		return nil, errStateVersion
+	}
+	if len(b) != stateHeaderSize+bodySize+stateChecksumSize {
+		return nil, errStateInvalid
+	}
+
+	n := len(b) - stateChecksumSize
+	mac := hmac.New(sha256.New, stateChecksumKey(key))
+	mac.Write(b[:n])
+	if !hmac.Equal(mac.Sum(nil)[:stateChecksumSize], b[n:]) {
+		return nil, errStateChecksum
+	}
+	return b[stateHeaderSize:n], nil
+}
+
+const words128x2Size = len(impl.Words128x2{}) * len(impl.Words128x2{}[0])
+
+func appendWords128x2(b []byte, w *impl.Words128x2) []byte {
+	for i := range w {
+		b = append(b, w[i][:]...)
+	}
+	return b
+}
+
+func consumeWords128x2(b []byte, w *impl.Words128x2) []byte {
+	for i := range w {
+		b = b[copy(w[i][:], b):]
+	}
+	return b
+}
+
+func consumeUint64(b []byte) ([]byte, uint64) {
+	return b[8:], binary.BigEndian.Uint64(b)
+}
+
+var (
+	_ encoding.BinaryAppender    = (*Hash128x2)(nil)
+	_ encoding.BinaryMarshaler   = (*Hash128x2)(nil)
+	_ encoding.BinaryUnmarshaler = (*Hash128x2)(nil)
+
+	_ encoding.BinaryAppender    = (*Sealer128x2)(nil)
+	_ encoding.BinaryMarshaler   = (*Sealer128x2)(nil)
+	_ encoding.BinaryUnmarshaler = (*Sealer128x2)(nil)
+
+	_ encoding.BinaryAppender    = (*Opener128x2)(nil)
+	_ encoding.BinaryMarshaler   = (*Opener128x2)(nil)
+	_ encoding.BinaryUnmarshaler = (*Opener128x2)(nil)
+)
+
+// nonce || tagSize || state || len || n || buf
+const hash128x2BodySize = 16 + 1 + words128x2Size + 8 + 1 + 64
+
+// AppendBinary appends the encoded state of h to b.
+func (h *Hash128x2) AppendBinary(b []byte) ([]byte, error) {
+	start := len(b)
+	b = appendStateHeader(b, stateKindHash128x2)
+	b = append(b, h.nonce[:]...)
+	b = append(b, byte(h.tagSize))
+	b = appendWords128x2(b, &h.state.w)
+	b = binary.BigEndian.AppendUint64(b, h.len)
+	b = append(b, byte(h.n))
+	b = append(b, h.buf[:]...)
+	return appendStateChecksum(b, start, h.key[:]), nil
+}
+
+// MarshalBinary returns the encoded state of h, including its nonce and the
+// data written so far, but not its key.
+func (h *Hash128x2) MarshalBinary() ([]byte, error) {
+	return h.AppendBinary(make([]byte, 0, stateHeaderSize+hash128x2BodySize+stateChecksumSize))
+}
+
+// UnmarshalBinary restores a state encoded by MarshalBinary. h must have been
+// created by a Mac128x2 with the same key, with any nonce and tag size; both
+// are replaced by those of the encoded state.
+func (h *Hash128x2) UnmarshalBinary(b []byte) error {
+	b, err := openState(b, stateKindHash128x2, hash128x2BodySize, h.key[:])
+	if err != nil {
+		return err
+	}
+
+	var d Hash128x2
+	d.key = h.key
+	b = b[copy(d.nonce[:], b):]
+	d.tagSize = int(b[0])
+	b = consumeWords128x2(b[1:], &d.state.w)
+	b, d.len = consumeUint64(b)
+	d.n = int(b[0])
+	copy(d.buf[:], b[1:])
+
+	if (d.tagSize != 16 && d.tagSize != 32) || d.n != int(d.len%64) || checkLengths(0, d.len) != nil {
+		return errStateInconsistent
+	}
+	*h = d
+	return nil
+}
+
+// state || adlen || msglen || inMessage || n || buf
+const stream128x2BodySize = words128x2Size + 8 + 8 + 1 + 1 + 64
+
+func (s *stream128x2) appendBinary(b []byte, kind byte) ([]byte, error) {
+	if s.finished {
+		return nil, errStateFinished
+	}
+
+	start := len(b)
+	b = appendStateHeader(b, kind)
+	b = appendWords128x2(b, &s.state.w)
+	b = binary.BigEndian.AppendUint64(b, s.adlen)
+	b = binary.BigEndian.AppendUint64(b, s.msglen)
+	if s.inMessage {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = append(b, byte(s.n))
+	b = append(b, s.buf[:]...)
+	return appendStateChecksum(b, start, s.key[:]), nil
+}
+
+func (s *stream128x2) unmarshalBinary(b []byte, kind byte) error {
+	if s.finished {
+		return errStateFinished
+	}
+	b, err := openState(b, kind, stream128x2BodySize, s.key[:])
+	if err != nil {
+		return err
+	}
+
+	var d stream128x2
+	d.key = s.key
+	b = consumeWords128x2(b, &d.state.w)
+	b, d.adlen = consumeUint64(b)
+	b, d.msglen = consumeUint64(b)
+	inMessage := b[0]
+	d.inMessage = inMessage == 1
+	d.n = int(b[1])
+	copy(d.buf[:], b[2:])
+
+	pending := d.adlen
+	if d.inMessage {
+		pending = d.msglen
+	}
+	if inMessage > 1 || d.n != int(pending%64) || (!d.inMessage && d.msglen != 0) || checkLengths(d.msglen, d.adlen) != nil {
+		return errStateInconsistent
+	}
+	if d.inMessage && d.n > 0 {
+		d.computeKeyStream()
+	}
+	*s = d
+	return nil
+}
+
+// AppendBinary appends the encoded state of s to b.
+func (s *Sealer128x2) AppendBinary(b []byte) ([]byte, error) {
+	return s.s.appendBinary(b, stateKindSealer128x2)
+}
+
+// MarshalBinary returns the encoded state of s, so that encryption can resume
+// where it stopped. It returns an error once s has been finished.
+//
+// An encoded Sealer must be resumed at most once. Resuming it twice and
+// encrypting different plaintexts reuses the keystream, exactly as reusing a
+// nonce would, and reveals the XOR of the two plaintexts. The encoding also
+// holds the plaintext of any incomplete 64-byte block in the clear, so it must
+// be protected like the plaintext itself, as well as like the key.
+func (s *Sealer128x2) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(make([]byte, 0, stateHeaderSize+stream128x2BodySize+stateChecksumSize))
+}
+
+// UnmarshalBinary restores a state encoded by MarshalBinary. s must have been
+// returned by NewSealer of an AEAD128x2 with the same key, with any nonce, and
+// not been finished.
+func (s *Sealer128x2) UnmarshalBinary(b []byte) error {
+	return s.s.unmarshalBinary(b, stateKindSealer128x2)
+}
+
+// AppendBinary appends the encoded state of o to b.
+func (o *Opener128x2) AppendBinary(b []byte) ([]byte, error) {
+	return o.s.appendBinary(b, stateKindOpener128x2)
+}
+
+// MarshalBinary returns the encoded state of o, as Sealer128x2.MarshalBinary
+// does. Resuming an Opener more than once is safe, but the encoding holds
+// decrypted, unauthenticated plaintext of any incomplete 64-byte block in the
+// clear.
+func (o *Opener128x2) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, stateHeaderSize+stream128x2BodySize+stateChecksumSize))
+}
+
+// UnmarshalBinary restores a state encoded by MarshalBinary. o must have been
+// returned by NewOpener of an AEAD128x2 with the same key, with any nonce, and
+// not been finished.
+func (o *Opener128x2) UnmarshalBinary(b []byte) error {
+	return o.s.unmarshalBinary(b, stateKindOpener128x2)
+}
@@ -0,0 +1,230 @@
+package aegis_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// resumeOnPortable runs f with the portable implementation, so that states
+// encoded by other implementations are decoded by a different one.
+func resumeOnPortable(t *testing.T, f func()) {
+	restore, ok := aegis.SetImplementation("portable")
+	if !ok {
+		t.Fatal("portable implementation not supported")
+	}
+	defer restore()
+	f()
+}
+
+func TestAegisMac128x2HashMarshal(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(21, 22))
+		for _, length := range []int{0, 1, 63, 64, 65, 200, 1000} {
+			key := ([16]byte)(randomBytes(rng, 16))
+			nonce := randomBytes(rng, 16)
+			data := randomBytes(rng, length)
+			mac := aegis.NewMac128x2(key)
+			want := mac.Sum32(nonce, data)
+
+			h, err := mac.NewHash(nonce, 32)
+			if err != nil {
+				t.Fatal(err)
+			}
+			split := rng.IntN(length + 1)
+			h.Write(data[:split])
+			blob, err := h.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resumeOnPortable(t, func() {
+				// The nonce and tag size come from the encoded state.
+				resumed, err := mac.NewHash(make([]byte, 16), 16)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := resumed.UnmarshalBinary(blob); err != nil {
+					t.Fatalf("UnmarshalBinary() = %v", err)
+				}
+				resumed.Write(data[split:])
+				if got := resumed.Sum(nil); !bytes.Equal(got, want[:]) {
+					t.Errorf("len=%d split=%d: resumed Sum() = %x, want %x", length, split, got, want)
+				}
+
+				// Reset must return to the encoded nonce.
+				resumed.Reset()
+				resumed.Write(data)
+				if got := resumed.Sum(nil); !bytes.Equal(got, want[:]) {
+					t.Errorf("len=%d: Sum() after Reset = %x, want %x", length, got, want)
+				}
+			})
+		}
+	})
+}
+
+func TestAegis128x2StreamMarshal(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(23, 24))
+		for range 50 {
+			key := ([16]byte)(randomBytes(rng, 16))
+			nonce := randomBytes(rng, 16)
+			aad := randomBytes(rng, rng.IntN(200))
+			plaintext := randomBytes(rng, rng.IntN(300))
+			aead := aegis.NewAEAD128x2(key)
+			wantCiphertext, wantTag := aead.DetachedSeal16(nil, nonce, plaintext, aad)
+
+			// Checkpoint either in the additional data or in the message.
+			aadSplit := rng.IntN(len(aad) + 1)
+			ptSplit := 0
+			if rng.IntN(2) == 0 {
+				aadSplit = len(aad)
+				ptSplit = rng.IntN(len(plaintext) + 1)
+			}
+
+			sealer, err := aead.NewSealer(nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sealer.WriteAAD(aad[:aadSplit])
+			ciphertext := make([]byte, len(plaintext))
+			if ptSplit > 0 {
+				sealer.XORKeyStream(ciphertext, plaintext[:ptSplit])
+			}
+			blob, err := sealer.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resumeOnPortable(t, func() {
+				resumed, err := aead.NewSealer(make([]byte, 16))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := resumed.UnmarshalBinary(blob); err != nil {
+					t.Fatalf("UnmarshalBinary() = %v", err)
+				}
+				if ptSplit == 0 {
+					resumed.WriteAAD(aad[aadSplit:])
+				}
+				resumed.XORKeyStream(ciphertext[ptSplit:], plaintext[ptSplit:])
+				if !bytes.Equal(ciphertext, wantCiphertext) {
+					t.Errorf("resumed ciphertext = %x, want %x", ciphertext, wantCiphertext)
+				}
+				if tag := resumed.Finish16(); tag != wantTag {
+					t.Errorf("resumed Finish16() = %x, want %x", tag, wantTag)
+				}
+			})
+
+			opener, err := aead.NewOpener(nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opener.WriteAAD(aad[:aadSplit])
+			got := make([]byte, len(plaintext))
+			if ptSplit > 0 {
+				opener.XORKeyStream(got, wantCiphertext[:ptSplit])
+			}
+			blob, err = opener.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resumeOnPortable(t, func() {
+				resumed, err := aead.NewOpener(make([]byte, 16))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := resumed.UnmarshalBinary(blob); err != nil {
+					t.Fatalf("UnmarshalBinary() = %v", err)
+				}
+				if ptSplit == 0 {
+					resumed.WriteAAD(aad[aadSplit:])
+				}
+				resumed.XORKeyStream(got[ptSplit:], wantCiphertext[ptSplit:])
+				if err := resumed.Finish16(wantTag); err != nil {
+					t.Errorf("resumed Finish16() = %v", err)
+				}
+				if !bytes.Equal(got, plaintext) {
+					t.Errorf("resumed plaintext = %x, want %x", got, plaintext)
+				}
+			})
+		}
+	})
+}
+
+func TestAegis128x2MarshalErrors(t *testing.T) {
+	rng := rand.New(rand.NewPCG(25, 26))
+	key := ([16]byte)(randomBytes(rng, 16))
+	nonce := randomBytes(rng, 16)
+	aead := aegis.NewAEAD128x2(key)
+
+	sealer, err := aead.NewSealer(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealer.WriteAAD(randomBytes(rng, 10))
+	sealer.XORKeyStream(make([]byte, 70), make([]byte, 70))
+	blob, err := sealer.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checksum is keyed with a key derived from the AEGIS key, not with the
+	// AEGIS key itself.
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte("aegis state checksum"))
+	mac = hmac.New(sha256.New, mac.Sum(nil))
+	mac.Write(blob[:len(blob)-16])
+	if got, want := blob[len(blob)-16:], mac.Sum(nil)[:16]; !bytes.Equal(got, want) {
+		t.Errorf("checksum = %x, want %x", got, want)
+	}
+
+	newSealer := func(key [16]byte) *aegis.Sealer128x2 {
+		s, err := aegis.NewAEAD128x2(key).NewSealer(nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	for i := range blob {
+		if err := newSealer(key).UnmarshalBinary(bytesWithFlippedBit(blob, i)); err == nil {
+			t.Errorf("UnmarshalBinary(blob with byte %d flipped) succeeded", i)
+		}
+	}
+	if err := newSealer(key).UnmarshalBinary(blob[:len(blob)-1]); err == nil {
+		t.Error("UnmarshalBinary(truncated blob) succeeded")
+	}
+	if err := newSealer(key).UnmarshalBinary(append(bytes.Clone(blob), 0)); err == nil {
+		t.Error("UnmarshalBinary(extended blob) succeeded")
+	}
+	if err := newSealer([16]byte{}).UnmarshalBinary(blob); err == nil {
+		t.Error("UnmarshalBinary with the wrong key succeeded")
+	}
+
+	opener, err := aead.NewOpener(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opener.UnmarshalBinary(blob); err == nil {
+		t.Error("Opener.UnmarshalBinary(Sealer state) succeeded")
+	}
+
+	hash, err := aegis.NewMac128x2(key).NewHash(nonce, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hash.UnmarshalBinary(blob); err == nil {
+		t.Error("Hash128x2.UnmarshalBinary(Sealer state) succeeded")
+	}
+
+	sealer.Finish16()
+	if _, err := sealer.MarshalBinary(); err == nil {
+		t.Error("MarshalBinary after Finish16 succeeded")
+	}
+}
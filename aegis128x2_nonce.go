@@ -0,0 +1,153 @@
+package aegis
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+)
+
+// NonceSequence generates the nonces of AEAD128x2 from a fixed 8-byte prefix
+// followed by a 64-bit big-endian counter, which starts at zero. Every nonce
+// it returns is distinct, and once the counter has taken every value, Next
+// returns ErrNoncesExhausted rather than wrapping around.
+//
+// Senders that share a key must use distinct prefixes. A NonceSequence is safe
+// for concurrent use.
+type NonceSequence struct {
+	prefix [8]byte
+
+	mu        sync.Mutex
+	next      uint64
+	exhausted bool
+}
+
+// NewNonceSequence returns a NonceSequence whose nonces start with prefix.
+func NewNonceSequence(prefix [8]byte) *NonceSequence {
+	return &NonceSequence{prefix: prefix}
+}
+
+// Next returns the next nonce of the sequence.
+func (s *NonceSequence) Next() ([16]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exhausted {
+		return [16]byte{}, ErrNoncesExhausted
+	}
+	var nonce [16]byte
+	copy(nonce[:8], s.prefix[:])
+	binary.BigEndian.PutUint64(nonce[8:], s.next)
+	s.next++
+	s.exhausted = s.next == 0
+	return nonce, nil
+}
+
+// RandomNonceMessageLimit is the number of messages that can be sealed with
+// random nonces under one key while keeping the probability that two nonces
+// collide below 2^-33.
+const RandomNonceMessageLimit = 1 << 48
+
+// SealWithRandomNonce seals plaintext like Seal, under a nonce drawn from
+// crypto/rand, and appends that nonce followed by the ciphertext and tag to
+// dst. Use OpenWithPrefixedNonce to open the result.
+//
+// To seal in place, put the plaintext at buf[16:] and use buf[:0] as dst.
+//
+// No more than RandomNonceMessageLimit messages should be sealed this way
+// under one key; LimitedAEAD128x2 can enforce that.
+func (a AEAD128x2) SealWithRandomNonce(dst, plaintext, additionalData []byte) []byte {
+	ret, out := sliceForAppend(dst, 16+len(plaintext)+a.Overhead())
+	checkPrefixedAliasing(out, 16, plaintext, additionalData)
+
+	rand.Read(out[:16])
+	a.Seal(out[:16], out[:16], plaintext, additionalData)
+	return ret
+}
+
+// OpenWithPrefixedNonce opens a message sealed by SealWithRandomNonce, whose
+// first 16 bytes are its nonce, and appends the plaintext to dst.
+//
+// To reuse ciphertext's storage for the plaintext, use ciphertext[16:16] as
+// dst.
+func (a AEAD128x2) OpenWithPrefixedNonce(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < 16+a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	return a.Open(dst, ciphertext[:16], ciphertext[16:], additionalData)
+}
+
+// LimitedAEAD128x2 is an AEAD128x2 that counts the messages it seals and
+// returns ErrMessageLimit instead of sealing more than a fixed number of them,
+// so that a key is replaced before it reaches a safety bound, such as
+// RandomNonceMessageLimit for random nonces. The count covers every sealing
+// method and is kept in memory only: it starts again from zero for a new
+// LimitedAEAD128x2, even with the same key.
+//
+// A LimitedAEAD128x2 is safe for concurrent use.
+type LimitedAEAD128x2 struct {
+	a     AEAD128x2
+	limit uint64
+
+	mu     sync.Mutex
+	sealed uint64
+}
+
+// NewLimitedAEAD128x2 returns a LimitedAEAD128x2 that seals at most
+// maxMessages messages under key.
+func NewLimitedAEAD128x2(key [16]byte, maxMessages uint64) *LimitedAEAD128x2 {
+	return &LimitedAEAD128x2{a: NewAEAD128x2(key), limit: maxMessages}
+}
+
+// reserve counts one more sealed message, unless the limit has been reached.
+func (l *LimitedAEAD128x2) reserve() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sealed >= l.limit {
+		return ErrMessageLimit
+	}
+	l.sealed++
+	return nil
+}
+
+// Sealed returns the number of messages sealed so far.
+func (l *LimitedAEAD128x2) Sealed() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sealed
+}
+
+// Remaining returns the number of messages that can still be sealed.
+func (l *LimitedAEAD128x2) Remaining() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit - l.sealed
+}
+
+// Seal is AEAD128x2.Seal, unless the limit has been reached.
+func (l *LimitedAEAD128x2) Seal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if err := l.reserve(); err != nil {
+		return nil, err
+	}
+	return l.a.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// SealWithRandomNonce is AEAD128x2.SealWithRandomNonce, unless the limit has
+// been reached.
+func (l *LimitedAEAD128x2) SealWithRandomNonce(dst, plaintext, additionalData []byte) ([]byte, error) {
+	if err := l.reserve(); err != nil {
+		return nil, err
+	}
+	return l.a.SealWithRandomNonce(dst, plaintext, additionalData), nil
+}
+
+// Open is AEAD128x2.Open. Opening does not count towards the limit.
+func (l *LimitedAEAD128x2) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return l.a.Open(dst, nonce, ciphertext, additionalData)
+}
+
+// OpenWithPrefixedNonce is AEAD128x2.OpenWithPrefixedNonce. Opening does not
+// count towards the limit.
+func (l *LimitedAEAD128x2) OpenWithPrefixedNonce(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	return l.a.OpenWithPrefixedNonce(dst, ciphertext, additionalData)
+}
@@ -0,0 +1,189 @@
+package aegis_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestNonceSequence(t *testing.T) {
+	prefix := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	s := aegis.NewNonceSequence(prefix)
+
+	for i := range 3 {
+		nonce, err := s.Next()
+		want := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 15: byte(i)}
+		if nonce != want || err != nil {
+			t.Fatalf("Next() = %x, %v; want %x, nil", nonce, err, want)
+		}
+	}
+
+	s.SetNext(math.MaxUint64 - 1)
+	for _, want := range []uint64{math.MaxUint64 - 1, math.MaxUint64} {
+		nonce, err := s.Next()
+		if err != nil || !bytes.Equal(nonce[8:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, byte(want)}) {
+			t.Fatalf("Next() = %x, %v; want counter %x", nonce, err, want)
+		}
+	}
+	for range 2 {
+		if nonce, err := s.Next(); err != aegis.ErrNoncesExhausted {
+			t.Fatalf("Next() = %x, %v; want %v", nonce, err, aegis.ErrNoncesExhausted)
+		}
+	}
+}
+
+func TestNonceSequenceConcurrent(t *testing.T) {
+	s := aegis.NewNonceSequence([8]byte{})
+	const goroutines, perGoroutine = 8, 1000
+
+	var mu sync.Mutex
+	seen := make(map[[16]byte]bool)
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perGoroutine {
+				nonce, err := s.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[nonce] {
+					t.Errorf("nonce %x returned twice", nonce)
+				}
+				seen[nonce] = true
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("got %d distinct nonces, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
+
+func TestAegis128x2RandomNonce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(81, 82))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+
+	for _, length := range []int{0, 1, 63, 64, 65, 1000} {
+		plaintext, ad := randomBytes(rng, length), randomBytes(rng, length/2)
+		prefix := randomBytes(rng, 5)
+
+		sealed := aead.SealWithRandomNonce(prefix, plaintext, ad)
+		if len(sealed) != len(prefix)+16+length+16 || !bytes.Equal(sealed[:len(prefix)], prefix) {
+			t.Fatalf("len=%d: SealWithRandomNonce returned %d bytes", length, len(sealed))
+		}
+		ciphertext := sealed[len(prefix):]
+		if want := aead.Seal(nil, ciphertext[:16], plaintext, ad); !bytes.Equal(ciphertext[16:], want) {
+			t.Errorf("len=%d: output is not the nonce followed by Seal's output", length)
+		}
+		if again := aead.SealWithRandomNonce(nil, plaintext, ad); bytes.Equal(again[:16], ciphertext[:16]) {
+			t.Errorf("len=%d: two calls used the same nonce", length)
+		}
+
+		got, err := aead.OpenWithPrefixedNonce(nil, ciphertext, ad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("len=%d: OpenWithPrefixedNonce() = %x, %v", length, got, err)
+		}
+		inPlace := bytes.Clone(ciphertext)
+		got, err = aead.OpenWithPrefixedNonce(inPlace[16:16], inPlace, ad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("len=%d: in-place OpenWithPrefixedNonce() = %x, %v", length, got, err)
+		}
+
+		// Sealing in place, with the plaintext after room for the nonce.
+		buf := make([]byte, 16+length, 16+length+16)
+		copy(buf[16:], plaintext)
+		sealed = aead.SealWithRandomNonce(buf[:0], buf[16:], ad)
+		if &sealed[0] != &buf[0] || !bytes.Equal(sealed[16:], aead.Seal(nil, sealed[:16], plaintext, ad)) {
+			t.Errorf("len=%d: in-place SealWithRandomNonce() is wrong", length)
+		}
+
+		for _, i := range []int{0, 15, 16, len(ciphertext) - 1} {
+			if _, err := aead.OpenWithPrefixedNonce(nil, bytesWithFlippedBit(ciphertext, i), ad); err != aegis.ErrAuthFailed {
+				t.Errorf("len=%d: flipped byte %d: err = %v, want %v", length, i, err, aegis.ErrAuthFailed)
+			}
+		}
+	}
+
+	if _, err := aead.OpenWithPrefixedNonce(nil, make([]byte, 31), nil); err != aegis.ErrCiphertextTooShort {
+		t.Errorf("short ciphertext: err = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+	buf := make([]byte, 100)
+	mustPanic(t, "SealWithRandomNonce over the plaintext", func() { aead.SealWithRandomNonce(buf[:0], buf[:50], nil) })
+	mustPanic(t, "SealWithRandomNonce with the nonce over the plaintext", func() { aead.SealWithRandomNonce(buf[:0], buf[8:10], nil) })
+	mustPanic(t, "SealWithRandomNonce with the nonce over the additional data", func() { aead.SealWithRandomNonce(buf[:0], nil, buf[10:12]) })
+}
+
+func TestLimitedAEAD128x2(t *testing.T) {
+	rng := rand.New(rand.NewPCG(83, 84))
+	key := ([16]byte)(randomBytes(rng, 16))
+	aead := aegis.NewAEAD128x2(key)
+	l := aegis.NewLimitedAEAD128x2(key, 3)
+	nonce := randomBytes(rng, 16)
+
+	sealed, err := l.Seal(nil, nonce, []byte("one"), nil)
+	if err != nil || !bytes.Equal(sealed, aead.Seal(nil, nonce, []byte("one"), nil)) {
+		t.Fatalf("Seal() = %x, %v", sealed, err)
+	}
+	if got, err := l.Open(nil, nonce, sealed, nil); err != nil || string(got) != "one" {
+		t.Fatalf("Open() = %q, %v", got, err)
+	}
+	prefixed, err := l.SealWithRandomNonce(nil, []byte("two"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := l.OpenWithPrefixedNonce(nil, prefixed, nil); err != nil || string(got) != "two" {
+		t.Fatalf("OpenWithPrefixedNonce() = %q, %v", got, err)
+	}
+	if _, err := l.Seal(nil, nonce, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if l.Sealed() != 3 || l.Remaining() != 0 {
+		t.Errorf("Sealed() = %d, Remaining() = %d; want 3, 0", l.Sealed(), l.Remaining())
+	}
+
+	if _, err := l.Seal(nil, nonce, nil, nil); !errors.Is(err, aegis.ErrMessageLimit) {
+		t.Errorf("Seal past the limit: err = %v, want %v", err, aegis.ErrMessageLimit)
+	}
+	if _, err := l.SealWithRandomNonce(nil, nil, nil); !errors.Is(err, aegis.ErrMessageLimit) {
+		t.Errorf("SealWithRandomNonce past the limit: err = %v, want %v", err, aegis.ErrMessageLimit)
+	}
+	if got, err := l.Open(nil, nonce, sealed, nil); err != nil || string(got) != "one" {
+		t.Errorf("Open past the limit = %q, %v", got, err)
+	}
+	if l.Sealed() != 3 {
+		t.Errorf("Sealed() = %d after refusals, want 3", l.Sealed())
+	}
+}
+
+func TestLimitedAEAD128x2Concurrent(t *testing.T) {
+	l := aegis.NewLimitedAEAD128x2([16]byte{}, 100)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var ok, limited int
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				_, err := l.SealWithRandomNonce(nil, nil, nil)
+				mu.Lock()
+				if err == nil {
+					ok++
+				} else {
+					limited++
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if ok != 100 || limited != 300 {
+		t.Errorf("sealed %d and refused %d messages, want 100 and 300", ok, limited)
+	}
+}
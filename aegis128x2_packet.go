@@ -0,0 +1,236 @@
+package aegis
+
+import (
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PacketConn encrypts the datagrams of a net.PacketConn with AEAD128x2. It is
+// created with NewClientPacketConn or NewServerPacketConn.
+//
+// Clients and servers seal their datagrams under different keys, derived from
+// the AEAD128x2 key with HKDF-SHA256 and the labels "aegis packet client" and
+// "aegis packet server", and only open datagrams sealed under the other's key.
+// A datagram reflected back to the PacketConn that sent it, or to another
+// PacketConn of the same role, therefore fails to authenticate.
+//
+// Every datagram is sent as a 16-byte header, followed by the ciphertext and
+// the 16-byte tag. The header holds an 8-byte ID drawn at random when the
+// PacketConn is created, which keeps the nonces of PacketConns sharing a key
+// apart, and the 64-bit big-endian packet number of the datagram, which
+// counts up from zero. The header is both the nonce and the additional data
+// of the datagram, so a receiver knows which nonce to use and the packet
+// number cannot be modified.
+//
+// The receiver keeps, for each sender ID, a window of the last
+// ReplayWindowSize packet numbers in the style of IPsec and DTLS. Datagrams
+// may arrive out of order within the window, but datagrams that were already
+// received, that are older than the window, or that fail to authenticate are
+// dropped without being returned by ReadFrom. The windows of at most
+// MaxPacketSenders senders are kept; beyond that, the window of the sender
+// heard from least recently is dropped, and datagrams that sender sent before
+// may then be replayed once.
+//
+// Two PacketConns of the same role that draw the same ID reuse nonces. No
+// more than 2^16 PacketConns of each role should share a key, which keeps the
+// chance of that below 2^-33.
+type PacketConn struct {
+	conn     net.PacketConn
+	seal     AEAD128x2
+	open     AEAD128x2
+	senderID [8]byte
+	next     atomic.Uint64
+
+	readMu  sync.Mutex
+	readBuf []byte
+	windows map[uint64]*replayWindow
+	clock   uint64 // counts authenticated datagrams, to order windows by use
+}
+
+var _ net.PacketConn = (*PacketConn)(nil)
+
+// PacketOverhead is the number of bytes a PacketConn adds to every datagram.
+const PacketOverhead = 8 + 8 + 16
+
+// ReplayWindowSize is the number of packet numbers below the highest one
+// received from a sender that a PacketConn accepts out of order.
+const ReplayWindowSize = 1024
+
+// MaxPacketSenders is the number of senders whose replay windows a PacketConn
+// keeps.
+const MaxPacketSenders = 4096
+
+const maxDatagramSize = 1<<16 - 1
+
+// NewClientPacketConn returns a PacketConn that encrypts the datagrams sent
+// and received over conn with keys derived from a, and exchanges them with
+// PacketConns returned by NewServerPacketConn.
+func (a AEAD128x2) NewClientPacketConn(conn net.PacketConn) *PacketConn {
+	return a.newPacketConn(conn, "aegis packet client", "aegis packet server")
+}
+
+// NewServerPacketConn returns a PacketConn that exchanges datagrams with
+// PacketConns returned by NewClientPacketConn.
+func (a AEAD128x2) NewServerPacketConn(conn net.PacketConn) *PacketConn {
+	return a.newPacketConn(conn, "aegis packet server", "aegis packet client")
+}
+
+func (a AEAD128x2) newPacketConn(conn net.PacketConn, sealLabel, openLabel string) *PacketConn {
+	sealKey, _ := hkdf.Key(sha256.New, a.key[:], nil, sealLabel, 16)
+	openKey, _ := hkdf.Key(sha256.New, a.key[:], nil, openLabel, 16)
+	c := &PacketConn{
+		conn:    conn,
+		seal:    NewAEAD128x2([16]byte(sealKey)),
+		open:    NewAEAD128x2([16]byte(openKey)),
+		windows: make(map[uint64]*replayWindow),
+	}
+	rand.Read(c.senderID[:])
+	return c
+}
+
+// WriteTo encrypts p and sends it to addr. It is safe to call concurrently.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	pn := c.next.Add(1) - 1
+
+	out := make([]byte, 16, PacketOverhead+len(p))
+	copy(out, c.senderID[:])
+	binary.BigEndian.PutUint64(out[8:], pn)
+	out = c.seal.Seal(out, out[:16], p, out[:16])
+
+	if _, err := c.conn.WriteTo(out, addr); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ReadFrom reads the next valid datagram, decrypts it into p and returns the
+// number of bytes copied and the address it came from. Like a UDP socket, it
+// discards the part of the datagram that does not fit in p.
+func (c *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	if c.readBuf == nil {
+		c.readBuf = make([]byte, maxDatagramSize)
+	}
+	for {
+		n, addr, err := c.conn.ReadFrom(c.readBuf)
+		if err != nil {
+			return 0, nil, err
+		}
+		if n < PacketOverhead {
+			continue
+		}
+		packet := c.readBuf[:n]
+		header := packet[:16]
+		sender := binary.BigEndian.Uint64(header[:8])
+		pn := binary.BigEndian.Uint64(header[8:])
+
+		w := c.windows[sender]
+		if w != nil && !w.check(pn) {
+			continue
+		}
+		plaintext, err := c.open.Open(packet[16:16], header, packet[16:], header)
+		if err != nil {
+			continue
+		}
+		// Only authenticated datagrams create or move a window.
+		if w == nil {
+			if len(c.windows) >= MaxPacketSenders {
+				c.evictWindow()
+			}
+			w = new(replayWindow)
+			c.windows[sender] = w
+		}
+		c.clock++
+		w.used = c.clock
+		w.update(pn)
+		return copy(p, plaintext), addr, nil
+	}
+}
+
+// evictWindow drops the window of the sender heard from least recently.
+func (c *PacketConn) evictWindow() {
+	var oldest uint64
+	var oldestUsed uint64 = 1<<64 - 1
+	for sender, w := range c.windows {
+		if w.used < oldestUsed {
+			oldest, oldestUsed = sender, w.used
+		}
+	}
+	delete(c.windows, oldest)
+}
+
+// replayWindow records which of the last ReplayWindowSize packet numbers up
+// to the highest one received have been received.
+type replayWindow struct {
+	next uint64 // the highest packet number received, plus one
+	bits [ReplayWindowSize / 64]uint64
+	used uint64 // the PacketConn's clock when the sender was last heard from
+}
+
+func (w *replayWindow) bit(pn uint64) (*uint64, uint64) {
+	i := pn % ReplayWindowSize
+	return &w.bits[i/64], 1 << (i % 64)
+}
+
+// check reports whether pn is new and within the window.
+func (w *replayWindow) check(pn uint64) bool {
+	if pn >= w.next {
+		return true
+	}
+	if w.next-1-pn >= ReplayWindowSize {
+		return false
+	}
+	word, mask := w.bit(pn)
+	return *word&mask == 0
+}
+
+// update marks pn, which must have passed check, as received, sliding the
+// window forward if pn is the highest packet number received.
+func (w *replayWindow) update(pn uint64) {
+	if pn >= w.next {
+		if pn-w.next >= ReplayWindowSize {
+			clear(w.bits[:])
+		} else {
+			for i := w.next; i < pn; i++ {
+				word, mask := w.bit(i)
+				*word &^= mask
+			}
+		}
+		w.next = pn + 1
+	}
+	word, mask := w.bit(pn)
+	*word |= mask
+}
+
+// Close closes the underlying connection.
+func (c *PacketConn) Close() error {
+	return c.conn.Close()
+}
+
+// LocalAddr returns the local network address.
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection.
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection.
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
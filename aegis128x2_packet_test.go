@@ -0,0 +1,206 @@
+package aegis_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/balasanjay/aegis"
+)
+
+type memAddr string
+
+func (a memAddr) Network() string { return "mem" }
+func (a memAddr) String() string  { return string(a) }
+
+// memPacketConn is an in-memory net.PacketConn. Datagrams written to it are
+// recorded in sent, and reads return the datagrams queued in recv, then
+// net.ErrClosed.
+type memPacketConn struct {
+	sent [][]byte
+	recv [][]byte
+}
+
+func (c *memPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	c.sent = append(c.sent, bytes.Clone(p))
+	return len(p), nil
+}
+
+func (c *memPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if len(c.recv) == 0 {
+		return 0, nil, net.ErrClosed
+	}
+	n := copy(p, c.recv[0])
+	c.recv = c.recv[1:]
+	return n, memAddr("sender"), nil
+}
+
+func (c *memPacketConn) Close() error                       { return nil }
+func (c *memPacketConn) LocalAddr() net.Addr                { return memAddr("local") }
+func (c *memPacketConn) SetDeadline(t time.Time) error      { return nil }
+func (c *memPacketConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *memPacketConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// sendPackets sends n datagrams from a new client PacketConn, each holding its
+// index in two bytes, and returns them as sent on the wire.
+func sendPackets(t *testing.T, aead aegis.AEAD128x2, n int) [][]byte {
+	t.Helper()
+	raw := new(memPacketConn)
+	c := aead.NewClientPacketConn(raw)
+	for i := range n {
+		msg := []byte{byte(i), byte(i >> 8)}
+		if m, err := c.WriteTo(msg, memAddr("receiver")); m != len(msg) || err != nil {
+			t.Fatalf("WriteTo() = %d, %v", m, err)
+		}
+	}
+	for _, d := range raw.sent {
+		if len(d) != 2+aegis.PacketOverhead {
+			t.Fatalf("datagram is %d bytes, want %d", len(d), 2+aegis.PacketOverhead)
+		}
+	}
+	return raw.sent
+}
+
+// receivePackets delivers datagrams to a new server PacketConn and returns
+// the indices of those it accepted.
+func receivePackets(t *testing.T, aead aegis.AEAD128x2, datagrams [][]byte) []int {
+	t.Helper()
+	return readPackets(t, aead.NewServerPacketConn(&memPacketConn{recv: datagrams}))
+}
+
+// readPackets reads datagrams sent by sendPackets from c until it fails, and
+// returns the indices of those it accepted.
+func readPackets(t *testing.T, c *aegis.PacketConn) []int {
+	t.Helper()
+	var got []int
+	buf := make([]byte, 16)
+	for {
+		n, addr, err := c.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		if n != 2 || addr != memAddr("sender") {
+			t.Fatalf("ReadFrom() = %d, %v", n, addr)
+		}
+		got = append(got, int(buf[0])|int(buf[1])<<8)
+	}
+	return got
+}
+
+func TestPacketConn(t *testing.T) {
+	rng := rand.New(rand.NewPCG(71, 72))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	sent := sendPackets(t, aead, 2*aegis.ReplayWindowSize)
+
+	tests := []struct {
+		name  string
+		order []int
+		want  []int
+	}{
+		{"in order", []int{0, 1, 2, 3}, []int{0, 1, 2, 3}},
+		{"reordered", []int{3, 0, 2, 1}, []int{3, 0, 2, 1}},
+		{"replayed", []int{0, 1, 0, 2, 1, 2}, []int{0, 1, 2}},
+		{"gap", []int{0, 5, 3, 5, 1}, []int{0, 5, 3, 1}},
+		{"edge of window", []int{aegis.ReplayWindowSize, 1, 0, 1}, []int{aegis.ReplayWindowSize, 1}},
+		{"window slides", []int{0, 2*aegis.ReplayWindowSize - 1, aegis.ReplayWindowSize - 1, aegis.ReplayWindowSize}, []int{0, 2*aegis.ReplayWindowSize - 1, aegis.ReplayWindowSize}},
+		{"slot reused", []int{5, aegis.ReplayWindowSize + 6, aegis.ReplayWindowSize + 5, 6, 5}, []int{5, aegis.ReplayWindowSize + 6, aegis.ReplayWindowSize + 5}},
+	}
+	for _, tt := range tests {
+		var datagrams [][]byte
+		for _, i := range tt.order {
+			datagrams = append(datagrams, sent[i])
+		}
+		got := receivePackets(t, aead, datagrams)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: accepted %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPacketConnSenders(t *testing.T) {
+	rng := rand.New(rand.NewPCG(73, 74))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	a, b := sendPackets(t, aead, 2), sendPackets(t, aead, 2)
+
+	// Each sender has its own window, and its own nonces.
+	if bytes.Equal(a[0], b[0]) {
+		t.Fatal("two PacketConns sent identical datagrams")
+	}
+	got := receivePackets(t, aead, [][]byte{a[1], b[0], a[0], b[1], b[0]})
+	if want := []int{1, 0, 0, 1}; !slices.Equal(got, want) {
+		t.Errorf("accepted %v, want %v", got, want)
+	}
+}
+
+func TestPacketConnRejects(t *testing.T) {
+	rng := rand.New(rand.NewPCG(75, 76))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	other := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	sent := sendPackets(t, aead, 3)
+
+	datagrams := [][]byte{
+		sendPackets(t, other, 1)[0],
+		sent[0][:aegis.PacketOverhead-1],
+		bytesWithFlippedBit(sent[0], 3),
+		bytesWithFlippedBit(sent[0], 15),
+		bytesWithFlippedBit(sent[0], 16),
+		bytesWithFlippedBit(sent[0], len(sent[0])-1),
+		sent[0][:len(sent[0])-1],
+		sent[1],
+	}
+	if got, want := receivePackets(t, aead, datagrams), []int{1}; !slices.Equal(got, want) {
+		t.Errorf("accepted %v, want %v", got, want)
+	}
+
+	// A forged datagram with a high packet number must not move the window.
+	forged := bytes.Clone(sent[2])
+	forged[8] ^= 0x80
+	if got, want := receivePackets(t, aead, [][]byte{sent[2], forged, sent[0]}), []int{2, 0}; !slices.Equal(got, want) {
+		t.Errorf("accepted %v, want %v", got, want)
+	}
+}
+
+func TestPacketConnReflection(t *testing.T) {
+	rng := rand.New(rand.NewPCG(77, 78))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+
+	clientRaw, serverRaw := new(memPacketConn), new(memPacketConn)
+	client, server := aead.NewClientPacketConn(clientRaw), aead.NewServerPacketConn(serverRaw)
+	for i := range 2 {
+		client.WriteTo([]byte{byte(i), 0}, memAddr("server"))
+		server.WriteTo([]byte{byte(i), 0}, memAddr("client"))
+	}
+
+	// Each side accepts what the other sent, but not its own datagrams
+	// reflected back to it, nor those of another PacketConn of its role.
+	clientRaw.recv = slices.Concat(clientRaw.sent, serverRaw.sent)
+	if got, want := readPackets(t, client), []int{0, 1}; !slices.Equal(got, want) {
+		t.Errorf("client accepted %v, want %v", got, want)
+	}
+	serverRaw.recv = slices.Concat(serverRaw.sent, clientRaw.sent, sendPackets(t, aead, 1))
+	if got, want := readPackets(t, server), []int{0, 1, 0}; !slices.Equal(got, want) {
+		t.Errorf("server accepted %v, want %v", got, want)
+	}
+}
+
+func TestPacketConnMaxSenders(t *testing.T) {
+	rng := rand.New(rand.NewPCG(79, 80))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	a, b := sendPackets(t, aead, 2), sendPackets(t, aead, 1)
+	others := make([][]byte, aegis.MaxPacketSenders)
+	for i := range others {
+		others[i] = sendPackets(t, aead, 1)[0]
+	}
+
+	// a is heard from again before the windows fill up, so the window that
+	// b's first datagram evicts is that of the first other sender, not a's.
+	datagrams := slices.Concat([][]byte{a[0]}, others[:aegis.MaxPacketSenders-1], [][]byte{a[1], b[0], a[0], others[0]})
+	got := receivePackets(t, aead, datagrams)
+	want := slices.Concat([]int{0}, make([]int, aegis.MaxPacketSenders-1), []int{1, 0, 0})
+	if !slices.Equal(got, want) {
+		t.Errorf("accepted %d datagrams ending in %v, want %d ending in %v", len(got), got[max(0, len(got)-4):], len(want), want[len(want)-4:])
+	}
+}
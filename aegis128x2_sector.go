@@ -0,0 +1,101 @@
+package aegis
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+// SectorCipher seals fixed-size sectors, such as disk blocks or database
+// pages, with AEAD128x2, keeping the ciphertext the same size as the plaintext
+// and storing the tag out of band.
+//
+// The nonce of a sector is its index, as a 64-bit big-endian integer, followed
+// by 8 random bytes drawn every time the sector is sealed, so that sectors can
+// be rewritten in place without reusing a nonce. Those random bytes are stored
+// in the sector's tag, which is SectorTagSize128x2 bytes long: the random half of
+// the nonce, followed by the 16-byte AEAD tag. Binding the index into the nonce
+// means that a sector moved to another index fails to open.
+//
+// The random halves of the nonces of a sector collide, reusing a nonce, with a
+// chance that grows with the square of the number of times it is sealed. No
+// sector should be sealed more than 2^16 times under one key, which keeps that
+// chance below 2^-33 per sector.
+type SectorCipher struct {
+	a          AEAD128x2
+	sectorSize int
+}
+
+// SectorTagSize128x2 is the size of the out-of-band tag of a sector.
+const SectorTagSize128x2 = 8 + 16
+
+var (
+	errSectorSize    = errors.New("aegis: wrong sector size")
+	errSectorTagSize = errors.New("aegis: sector tag is not SectorTagSize128x2 bytes long")
+)
+
+// NewSectorCipher returns a SectorCipher for sectors of sectorSize bytes,
+// which must be positive.
+func (a AEAD128x2) NewSectorCipher(sectorSize int) (SectorCipher, error) {
+	if sectorSize <= 0 || uint64(sectorSize) > maxLen {
+		return SectorCipher{}, errSectorSize
+	}
+	return SectorCipher{a, sectorSize}, nil
+}
+
+// SectorSize returns the size of the sectors sealed by c.
+func (c SectorCipher) SectorSize() int {
+	return c.sectorSize
+}
+
+func sectorNonce(index uint64, salt []byte) [16]byte {
+	var nonce [16]byte
+	binary.BigEndian.PutUint64(nonce[:8], index)
+	copy(nonce[8:], salt)
+	return nonce
+}
+
+// SealSector encrypts the sector at index, appends its ciphertext to dst and
+// returns the result, and writes its tag into tagOut. plaintext must be
+// SectorSize bytes long, and tagOut at least SectorTagSize128x2 bytes long.
+//
+// To reuse plaintext's storage for the ciphertext, use plaintext[:0] as dst.
+// SealSector does not allocate if dst has enough capacity.
+func (c SectorCipher) SealSector(dst, tagOut []byte, index uint64, plaintext []byte) []byte {
+	if len(plaintext) != c.sectorSize {
+		panic(errSectorSize)
+	}
+	if len(tagOut) < SectorTagSize128x2 {
+		panic("aegis: tagOut is shorter than SectorTagSize128x2")
+	}
+
+	var salt [8]byte
+	rand.Read(salt[:])
+	nonce := sectorNonce(index, salt[:])
+
+	ret, tag := c.a.DetachedSeal16(dst, nonce[:], plaintext, nil)
+	copy(tagOut[:8], salt[:])
+	copy(tagOut[8:SectorTagSize128x2], tag[:])
+	return ret
+}
+
+// OpenSector decrypts and authenticates the sector at index, given its
+// ciphertext and tag, appends its plaintext to dst and returns the result. It
+// returns ErrAuthFailed, and overwrites the output with zeros, if the sector
+// was not sealed at index under this key, or was modified. It returns an
+// error without trying to open the sector if tag is not SectorTagSize128x2
+// bytes long.
+//
+// To reuse ciphertext's storage for the plaintext, use ciphertext[:0] as dst.
+// OpenSector does not allocate if dst has enough capacity.
+func (c SectorCipher) OpenSector(dst, tag []byte, index uint64, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) != c.sectorSize {
+		return nil, errSectorSize
+	}
+	if len(tag) != SectorTagSize128x2 {
+		return nil, errSectorTagSize
+	}
+
+	nonce := sectorNonce(index, tag[:8])
+	return c.a.DetachedOpen16(dst, nonce[:], ciphertext, nil, [16]byte(tag[8:]))
+}
@@ -0,0 +1,160 @@
+package aegis_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestAegis128x2Sector(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(51, 52))
+		aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+		for _, size := range []int{1, 512, 4096} {
+			c, err := aead.NewSectorCipher(size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			index := rng.Uint64()
+			plaintext := randomBytes(rng, size)
+
+			var tag [aegis.SectorTagSize128x2]byte
+			ciphertext := c.SealSector(nil, tag[:], index, plaintext)
+			if len(ciphertext) != size {
+				t.Fatalf("size=%d: ciphertext is %d bytes", size, len(ciphertext))
+			}
+
+			// A sector is an ordinary AEAD128x2 ciphertext.
+			nonce := append(binary.BigEndian.AppendUint64(nil, index), tag[:8]...)
+			if got, err := aead.DetachedOpen16(nil, nonce, ciphertext, nil, [16]byte(tag[8:])); err != nil || !bytes.Equal(got, plaintext) {
+				t.Fatalf("size=%d: DetachedOpen16() = %x, %v", size, got, err)
+			}
+
+			got, err := c.OpenSector(nil, tag[:], index, ciphertext)
+			if err != nil || !bytes.Equal(got, plaintext) {
+				t.Fatalf("size=%d: OpenSector() = %x, %v", size, got, err)
+			}
+
+			// Sealing the same sector again uses a fresh nonce.
+			var tag2 [aegis.SectorTagSize128x2]byte
+			if again := c.SealSector(nil, tag2[:], index, plaintext); bytes.Equal(again, ciphertext) || tag2 == tag {
+				t.Errorf("size=%d: sealing a sector twice gave the same result", size)
+			}
+
+			if _, err := c.OpenSector(nil, tag[:], index+1, ciphertext); !errors.Is(err, aegis.ErrAuthFailed) {
+				t.Errorf("size=%d: OpenSector(wrong index) = %v, want %v", size, err, aegis.ErrAuthFailed)
+			}
+			for _, i := range []int{0, 7, 8, aegis.SectorTagSize128x2 - 1} {
+				if _, err := c.OpenSector(nil, bytesWithFlippedBit(tag[:], i), index, ciphertext); !errors.Is(err, aegis.ErrAuthFailed) {
+					t.Errorf("size=%d: OpenSector(tag byte %d flipped) = %v, want %v", size, i, err, aegis.ErrAuthFailed)
+				}
+			}
+			if _, err := c.OpenSector(nil, tag[:], index, bytesWithFlippedBit(ciphertext, size-1)); !errors.Is(err, aegis.ErrAuthFailed) {
+				t.Errorf("size=%d: OpenSector(modified ciphertext) = %v, want %v", size, err, aegis.ErrAuthFailed)
+			}
+		}
+	})
+}
+
+func TestAegis128x2SectorInPlace(t *testing.T) {
+	c, err := aegis.NewAEAD128x2([16]byte{4}).NewSectorCipher(4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewPCG(53, 54))
+	plaintext := randomBytes(rng, 4096)
+	buf := bytes.Clone(plaintext)
+
+	var tag [aegis.SectorTagSize128x2]byte
+	c.SealSector(buf[:0], tag[:], 9, buf)
+	if _, err := c.OpenSector(buf[:0], tag[:], 9, buf); err != nil || !bytes.Equal(buf, plaintext) {
+		t.Fatalf("in-place OpenSector() = %v", err)
+	}
+
+	if allocs := testing.AllocsPerRun(10, func() {
+		c.SealSector(buf[:0], tag[:], 9, buf)
+		c.OpenSector(buf[:0], tag[:], 9, buf)
+	}); allocs != 0 {
+		t.Errorf("SealSector and OpenSector allocate %v times, want 0", allocs)
+	}
+}
+
+func TestAegis128x2SectorMisuse(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{})
+	if _, err := aead.NewSectorCipher(0); err == nil {
+		t.Error("NewSectorCipher(0) succeeded")
+	}
+	c, err := aead.NewSectorCipher(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tag [aegis.SectorTagSize128x2]byte
+	mustPanic(t, "SealSector with a short sector", func() { c.SealSector(nil, tag[:], 0, make([]byte, 511)) })
+	mustPanic(t, "SealSector with a short tag", func() { c.SealSector(nil, tag[:16], 0, make([]byte, 512)) })
+	if _, err := c.OpenSector(nil, tag[:], 0, make([]byte, 513)); err == nil {
+		t.Error("OpenSector with a long sector succeeded")
+	}
+	if _, err := c.OpenSector(nil, tag[:16], 0, make([]byte, 512)); !errors.Is(err, aegis.ErrSectorTagSize) {
+		t.Errorf("OpenSector with a short tag = %v, want %v", err, aegis.ErrSectorTagSize)
+	}
+}
+
+// TestAegis128x2SectorOtherFormat checks that sectors sealed in the format with
+// 32-byte tags, a 16-byte random nonce followed by the AEAD tag, with the
+// index as additional data, are rejected rather than misread.
+func TestAegis128x2SectorOtherFormat(t *testing.T) {
+	rng := rand.New(rand.NewPCG(55, 56))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	c, err := aead.NewSectorCipher(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const index = 7
+	plaintext, nonce := randomBytes(rng, 512), randomBytes(rng, 16)
+	ciphertext, aeadTag := aead.DetachedSeal16(nil, nonce, plaintext, binary.BigEndian.AppendUint64(nil, index))
+	tag := append(nonce, aeadTag[:]...)
+
+	if got, err := c.OpenSector(nil, tag, index, ciphertext); !errors.Is(err, aegis.ErrSectorTagSize) {
+		t.Errorf("OpenSector(32-byte tag) = %x, %v; want %v", got, err, aegis.ErrSectorTagSize)
+	}
+	// Cutting the tag down to size does not make it open either.
+	for _, short := range [][]byte{tag[:aegis.SectorTagSize128x2], tag[len(tag)-aegis.SectorTagSize128x2:]} {
+		if got, err := c.OpenSector(nil, short, index, ciphertext); !errors.Is(err, aegis.ErrAuthFailed) {
+			t.Errorf("OpenSector(cut 32-byte tag) = %x, %v; want %v", got, err, aegis.ErrAuthFailed)
+		}
+	}
+}
+
+func BenchmarkAegis128x2Sector(b *testing.B) {
+	for _, size := range []int{4096, 16384} {
+		c, err := aegis.NewAEAD128x2([16]byte{}).NewSectorCipher(size)
+		if err != nil {
+			b.Fatal(err)
+		}
+		buf := make([]byte, size)
+		var tag [aegis.SectorTagSize128x2]byte
+
+		b.Run(strconv.Itoa(size)+"/Seal", func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := uint64(0); b.Loop(); i++ {
+				c.SealSector(buf[:0], tag[:], i, buf)
+			}
+		})
+		b.Run(strconv.Itoa(size)+"/Open", func(b *testing.B) {
+			sealed := c.SealSector(nil, tag[:], 0, buf)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := c.OpenSector(buf[:0], tag[:], 0, sealed); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// SIV128x2 is a deterministic, nonce-misuse-resistant AEAD built from
+// AEGIS-128X2 with the SIV construction. Sealing the same plaintext with the
+// same additional data and nonce always gives the same ciphertext, which
+// suits deduplicated storage and key wrapping, and reusing a nonce only
+// reveals whether two messages are equal.
+//
+// Its 32-byte key is split into a Mac128x2 key, the first 16 bytes, and an
+// AEAD128x2 key, the last 16 bytes. The synthetic nonce of a message is the
+// 16-byte tag Mac128x2 computes, with the nonce as its nonce, over a 0x01
+// byte if the nonce is given or a 0x00 byte if not, the length of the
+// additional data as a 64-bit little-endian integer, the additional data and
+// the plaintext. The plaintext is encrypted with AEAD128x2 under the synthetic
+// nonce, with no additional data, and the ciphertext is followed by the
+// synthetic nonce in place of a tag.
+type SIV128x2 struct {
+	mac Mac128x2
+	enc AEAD128x2
+}
+
+var _ cipher.AEAD = SIV128x2{}
+
+func NewSIV128x2(key [32]byte) SIV128x2 {
+	return SIV128x2{
+		mac: NewMac128x2(([16]byte)(key[:16])),
+		enc: NewAEAD128x2(([16]byte)(key[16:])),
+	}
+}
+
+// NonceSize returns 16, but the nonce passed to Seal and Open may also be
+// empty, which makes Seal deterministic.
+func (s SIV128x2) NonceSize() int {
+	return 16
+}
+
+func (s SIV128x2) Overhead() int {
+	return 16
+}
+
+// syntheticNonce returns the synthetic nonce of a message. nonce must be empty
+// or 16 bytes long.
+func (s SIV128x2) syntheticNonce(nonce, plaintext, aad []byte) [16]byte {
+	var header [9]byte
+	var macNonce [16]byte
+	if len(nonce) != 0 {
+		header[0] = 1
+		copy(macNonce[:], nonce)
+	}
+	binary.LittleEndian.PutUint64(header[1:], uint64(len(aad)))
+	return s.mac.Sum16Vec(macNonce[:], [][]byte{header[:], aad, plaintext})
+}
+
+// Seal encrypts and authenticates plaintext and additional data as
+// cipher.AEAD does. nonce must be empty or NonceSize bytes long; without a
+// nonce, equal inputs give equal outputs.
+func (s SIV128x2) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != 0 && len(nonce) != s.NonceSize() {
+		panic(ErrInvalidNonceSize)
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+s.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	siv := s.syntheticNonce(nonce, plaintext, aad)
+	s.enc.detachedSeal(out[:0], siv[:], plaintext, nil)
+	copy(out[len(plaintext):], siv[:])
+
+	return ret
+}
+
+// Open decrypts and authenticates ciphertext as cipher.AEAD does. nonce must
+// be the one passed to Seal, empty or NonceSize bytes long.
+func (s SIV128x2) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != 0 && len(nonce) != s.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	if len(ciphertext) < s.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	if err := checkLengths(uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	n := len(ciphertext) - s.Overhead()
+	_, out := sliceForAppend(dst, n)
+	checkAliasing(out, ciphertext, aad)
+
+	siv := ciphertext[n:]
+	ret, out, _ := s.enc.detachedOpen(dst, siv, ciphertext[:n], nil)
+
+	expected := s.syntheticNonce(nonce, out, aad)
+	if subtle.ConstantTimeCompare(expected[:], siv) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+// WrapKey encrypts key, bound to additional data, for storage or transport
+// under s. Wrapping is deterministic, so it needs no nonce: the same key and
+// additional data always wrap to the same result.
+func (s SIV128x2) WrapKey(key, aad []byte) []byte {
+	return s.Seal(nil, nil, key, aad)
+}
+
+// UnwrapKey returns the key that WrapKey wrapped with the same additional
+// data, or ErrAuthFailed if wrapped was modified or wrapped under another key
+// or with other additional data.
+func (s SIV128x2) UnwrapKey(wrapped, aad []byte) ([]byte, error) {
+	return s.Open(nil, nil, wrapped, aad)
+}
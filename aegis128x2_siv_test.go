@@ -0,0 +1,149 @@
+package aegis_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestSIV128x2(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(111, 112))
+		key := ([32]byte)(randomBytes(rng, 32))
+		siv := aegis.NewSIV128x2(key)
+
+		for _, length := range []int{0, 1, 15, 16, 63, 64, 65, 1000} {
+			for _, nonce := range [][]byte{nil, randomBytes(rng, 16)} {
+				plaintext, ad := randomBytes(rng, length), randomBytes(rng, length/2)
+
+				sealed := siv.Seal(nil, nonce, plaintext, ad)
+				if len(sealed) != length+siv.Overhead() {
+					t.Fatalf("len=%d: Seal returned %d bytes", length, len(sealed))
+				}
+
+				// The synthetic nonce is Mac128x2 over the encoded inputs, and
+				// the ciphertext is AEAD128x2 under it.
+				header := []byte{0}
+				macNonce := make([]byte, 16)
+				if nonce != nil {
+					header[0] = 1
+					copy(macNonce, nonce)
+				}
+				header = binary.LittleEndian.AppendUint64(header, uint64(len(ad)))
+				data := append(append(header, ad...), plaintext...)
+				wantSIV := aegis.NewMac128x2(([16]byte)(key[:16])).Sum16(macNonce, data)
+				wantCiphertext, _ := aegis.NewAEAD128x2(([16]byte)(key[16:])).DetachedSeal16(nil, wantSIV[:], plaintext, nil)
+				if !bytes.Equal(sealed, append(wantCiphertext, wantSIV[:]...)) {
+					t.Errorf("len=%d nonce=%x: Seal() does not follow the construction", length, nonce)
+				}
+
+				got, err := siv.Open(nil, nonce, sealed, ad)
+				if err != nil || !bytes.Equal(got, plaintext) {
+					t.Fatalf("len=%d nonce=%x: Open() = %x, %v", length, nonce, got, err)
+				}
+				inPlace := bytes.Clone(sealed)
+				got, err = siv.Open(inPlace[:0], nonce, inPlace, ad)
+				if err != nil || !bytes.Equal(got, plaintext) {
+					t.Fatalf("len=%d nonce=%x: in-place Open() = %x, %v", length, nonce, got, err)
+				}
+				buf := bytes.Clone(plaintext)
+				if got := siv.Seal(buf[:0], nonce, buf, ad); !bytes.Equal(got, sealed) {
+					t.Errorf("len=%d nonce=%x: in-place Seal() differs", length, nonce)
+				}
+
+				for _, i := range []int{0, length / 2, length, len(sealed) - 1} {
+					if i >= len(sealed) {
+						continue
+					}
+					out := make([]byte, 0, len(sealed))
+					if _, err := siv.Open(out, nonce, bytesWithFlippedBit(sealed, i), ad); err != aegis.ErrAuthFailed {
+						t.Errorf("len=%d nonce=%x: flipped byte %d: err = %v, want %v", length, nonce, i, err, aegis.ErrAuthFailed)
+					}
+					if !bytes.Equal(out[:length], make([]byte, length)) {
+						t.Errorf("len=%d nonce=%x: flipped byte %d: plaintext was not cleared", length, nonce, i)
+					}
+				}
+				if _, err := siv.Open(nil, nonce, sealed, append(ad, 0)); err != aegis.ErrAuthFailed {
+					t.Errorf("len=%d nonce=%x: longer additional data: err = %v, want %v", length, nonce, err, aegis.ErrAuthFailed)
+				}
+			}
+		}
+	})
+}
+
+func TestSIV128x2Deterministic(t *testing.T) {
+	rng := rand.New(rand.NewPCG(113, 114))
+	siv := aegis.NewSIV128x2(([32]byte)(randomBytes(rng, 32)))
+	plaintext, ad, nonce := randomBytes(rng, 100), randomBytes(rng, 20), randomBytes(rng, 16)
+
+	for _, n := range [][]byte{nil, nonce} {
+		a, b := siv.Seal(nil, n, plaintext, ad), siv.Seal(nil, n, plaintext, ad)
+		if !bytes.Equal(a, b) {
+			t.Errorf("nonce=%x: identical inputs gave different ciphertexts", n)
+		}
+	}
+
+	// Changing any input changes the whole output.
+	base := siv.Seal(nil, nil, plaintext, ad)
+	for name, sealed := range map[string][]byte{
+		"plaintext":  siv.Seal(nil, nil, bytesWithFlippedBit(plaintext, 99), ad),
+		"ad":         siv.Seal(nil, nil, plaintext, bytesWithFlippedBit(ad, 0)),
+		"nonce":      siv.Seal(nil, nonce, plaintext, ad),
+		"zero nonce": siv.Seal(nil, make([]byte, 16), plaintext, ad),
+		"ad split":   siv.Seal(nil, nil, append(bytes.Clone(ad), plaintext[0]), plaintext[1:]),
+	} {
+		if bytes.Equal(sealed[:16], base[:16]) || bytes.Equal(sealed[len(sealed)-16:], base[len(base)-16:]) {
+			t.Errorf("changing the %s did not change the ciphertext and synthetic nonce", name)
+		}
+	}
+	if _, err := siv.Open(nil, make([]byte, 16), base, ad); err != aegis.ErrAuthFailed {
+		t.Errorf("opening a message sealed without a nonce with a zero nonce: err = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+}
+
+func TestSIV128x2KeyWrap(t *testing.T) {
+	rng := rand.New(rand.NewPCG(115, 116))
+	kek := aegis.NewSIV128x2(([32]byte)(randomBytes(rng, 32)))
+	other := aegis.NewSIV128x2(([32]byte)(randomBytes(rng, 32)))
+	dataKey, ad := randomBytes(rng, 16), []byte("volume 7")
+
+	wrapped := kek.WrapKey(dataKey, ad)
+	if len(wrapped) != len(dataKey)+16 {
+		t.Fatalf("WrapKey returned %d bytes, want %d", len(wrapped), len(dataKey)+16)
+	}
+	if again := kek.WrapKey(dataKey, ad); !bytes.Equal(again, wrapped) {
+		t.Errorf("WrapKey is not deterministic")
+	}
+	if got, err := kek.UnwrapKey(wrapped, ad); err != nil || !bytes.Equal(got, dataKey) {
+		t.Fatalf("UnwrapKey() = %x, %v", got, err)
+	}
+
+	for name, f := range map[string]func() ([]byte, error){
+		"other key": func() ([]byte, error) { return other.UnwrapKey(wrapped, ad) },
+		"other ad":  func() ([]byte, error) { return kek.UnwrapKey(wrapped, []byte("volume 8")) },
+		"modified":  func() ([]byte, error) { return kek.UnwrapKey(bytesWithFlippedBit(wrapped, 3), ad) },
+		"truncated": func() ([]byte, error) { return kek.UnwrapKey(wrapped[:len(wrapped)-1], ad) },
+	} {
+		if got, err := f(); err != aegis.ErrAuthFailed {
+			t.Errorf("%s: UnwrapKey() = %x, %v; want %v", name, got, err, aegis.ErrAuthFailed)
+		}
+	}
+}
+
+func TestSIV128x2Errors(t *testing.T) {
+	siv := aegis.NewSIV128x2([32]byte{})
+	mustPanic(t, "Seal with a 15-byte nonce", func() { siv.Seal(nil, make([]byte, 15), nil, nil) })
+	if _, err := siv.Open(nil, make([]byte, 17), make([]byte, 16), nil); err != aegis.ErrInvalidNonceSize {
+		t.Errorf("17-byte nonce: err = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, err := siv.Open(nil, nil, make([]byte, 15), nil); err != aegis.ErrCiphertextTooShort {
+		t.Errorf("short ciphertext: err = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+	buf := make([]byte, 100)
+	mustPanic(t, "Seal with overlapping buffers", func() { siv.Seal(buf[1:1], nil, buf[:50], nil) })
+	mustPanic(t, "Open with overlapping buffers", func() { siv.Open(buf[1:1], nil, buf[:50], nil) })
+	mustPanic(t, "Open into the additional data", func() { siv.Open(buf[:0], nil, buf[50:], buf[10:20]) })
+}
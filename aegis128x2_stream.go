@@ -0,0 +1,284 @@
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// Sealer128x2 encrypts a message with AEGIS-128X2 incrementally, producing the
+// same ciphertext and tag as a single call to Seal or DetachedSeal16/32.
+//
+// The additional data must be written with WriteAAD before any of the message
+// is encrypted with XORKeyStream. Finish16 or Finish32 then returns the tag, and
+// the Sealer must not be used afterwards.
+type Sealer128x2 struct {
+	s stream128x2
+}
+
+var _ cipher.Stream = (*Sealer128x2)(nil)
+
+// NewSealer returns a Sealer128x2 for the given nonce, which must be NonceSize
+// bytes long.
+func (a AEAD128x2) NewSealer(nonce []byte) (*Sealer128x2, error) {
+	if len(nonce) != a.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	s := new(Sealer128x2)
+	s.s.init(&a.key, (*[16]byte)(nonce))
+	return s, nil
+}
+
+// WriteAAD authenticates aad as additional data. It returns ErrMessageTooLong
+// if the additional data grows past what AEGIS allows, and panics if called
+// after XORKeyStream.
+func (s *Sealer128x2) WriteAAD(aad []byte) error {
+	return s.s.writeAAD(aad)
+}
+
+// XORKeyStream encrypts src into dst, which must be at least as long as src.
+// dst and src may overlap entirely or not at all.
+//
+// It panics with ErrMessageTooLong if the message grows past what AEGIS allows.
+func (s *Sealer128x2) XORKeyStream(dst, src []byte) {
+	s.s.xorKeyStream(dst, src, false)
+}
+
+// Finish16 returns the 16-byte tag for the additional data and message.
+func (s *Sealer128x2) Finish16() [16]byte {
+	s.s.finish()
+	tag := s.s.state.finalize16(s.s.adlen, s.s.msglen)
+	s.s.wipe()
+	return tag
+}
+
+// Finish32 returns the 32-byte tag for the additional data and message.
+func (s *Sealer128x2) Finish32() [32]byte {
+	s.s.finish()
+	tag := s.s.state.finalize32(s.s.adlen, s.s.msglen)
+	s.s.wipe()
+	return tag
+}
+
+// Opener128x2 decrypts a message sealed with AEGIS-128X2 incrementally, and
+// checks its tag.
+//
+// The tag only covers the whole message, so an Opener cannot tell whether any
+// plaintext is authentic before Finish16 or Finish32, and it does not try to
+// hold plaintext back until then: XORKeyStream writes unauthenticated plaintext
+// to dst straight away, as any cipher.Stream does. Keeping that plaintext from
+// being acted on, or released any further, until Finish16 or Finish32 has
+// returned nil is up to the caller, as is discarding all of it if they return
+// ErrAuthFailed. An Opener only refuses to be used again after Finish16 or
+// Finish32, and wipes its internal state.
+//
+// Callers that cannot hold back unauthenticated plaintext should use Open,
+// which returns no plaintext unless the tag matches, or a DecryptReader128x2,
+// which only returns plaintext from segments that have been authenticated.
+type Opener128x2 struct {
+	s stream128x2
+}
+
+var _ cipher.Stream = (*Opener128x2)(nil)
+
+// NewOpener returns an Opener128x2 for the given nonce, which must be
+// NonceSize bytes long.
+func (a AEAD128x2) NewOpener(nonce []byte) (*Opener128x2, error) {
+	if len(nonce) != a.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	o := new(Opener128x2)
+	o.s.init(&a.key, (*[16]byte)(nonce))
+	return o, nil
+}
+
+// WriteAAD authenticates aad as additional data, as Sealer128x2.WriteAAD does.
+func (o *Opener128x2) WriteAAD(aad []byte) error {
+	return o.s.writeAAD(aad)
+}
+
+// XORKeyStream decrypts src into dst, as Sealer128x2.XORKeyStream encrypts.
+// The plaintext it writes to dst is unauthenticated until Finish16 or Finish32
+// returns nil, and must be discarded if they return ErrAuthFailed.
+func (o *Opener128x2) XORKeyStream(dst, src []byte) {
+	o.s.xorKeyStream(dst, src, true)
+}
+
+// Finish16 checks the 16-byte tag of the additional data and message, and
+// returns ErrAuthFailed if it does not match.
+func (o *Opener128x2) Finish16(tag [16]byte) error {
+	o.s.finish()
+	expectedTag := o.s.state.finalize16(o.s.adlen, o.s.msglen)
+	o.s.wipe()
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// Finish32 checks the 32-byte tag of the additional data and message, and
+// returns ErrAuthFailed if it does not match.
+func (o *Opener128x2) Finish32(tag [32]byte) error {
+	o.s.finish()
+	expectedTag := o.s.state.finalize32(o.s.adlen, o.s.msglen)
+	o.s.wipe()
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// stream128x2 is the state shared by Sealer128x2 and Opener128x2.
+type stream128x2 struct {
+	// key is only kept to check encoded states; see MarshalBinary.
+	key   [16]byte
+	state state128x2
+
+	// buf holds the start of an incomplete 64-byte block: additional data
+	// before the message starts, and plaintext afterwards. n is its length.
+	buf [64]byte
+	n   int
+
+	// ks is the keystream for the incomplete block of the message in buf.
+	ks [64]byte
+
+	adlen, msglen uint64
+
+	inMessage bool
+	finished  bool
+}
+
+func (s *stream128x2) init(key, nonce *[16]byte) {
+	s.key = *key
+	s.state.init(key, nonce)
+}
+
+func (s *stream128x2) writeAAD(aad []byte) error {
+	s.checkNotFinished()
+	if s.inMessage {
+		panic("aegis: WriteAAD called after XORKeyStream")
+	}
+	if err := checkLengths(s.msglen, s.adlen+uint64(len(aad))); err != nil {
+		return err
+	}
+	s.adlen += uint64(len(aad))
+
+	if s.n > 0 {
+		k := copy(s.buf[s.n:], aad)
+		s.n += k
+		aad = aad[k:]
+		if s.n < 64 {
+			return nil
+		}
+		s.state.absorb(s.buf[:])
+		s.n = 0
+	}
+
+	full := len(aad) &^ 63
+	s.state.absorb(aad[:full])
+	s.n = copy(s.buf[:], aad[full:])
+	return nil
+}
+
+// startMessage pads and absorbs any incomplete block of additional data.
+func (s *stream128x2) startMessage() {
+	if s.inMessage {
+		return
+	}
+	s.inMessage = true
+	if s.n > 0 {
+		clear(s.buf[s.n:])
+		s.state.absorb(s.buf[:])
+		s.n = 0
+	}
+}
+
+func (s *stream128x2) xorKeyStream(dst, src []byte, decrypt bool) {
+	s.checkNotFinished()
+	if len(dst) < len(src) {
+		panic("aegis: output smaller than input")
+	}
+	dst = dst[:len(src)]
+	if inexactOverlap(dst, src) {
+		panic("aegis: invalid buffer overlap")
+	}
+	if err := checkLengths(s.msglen+uint64(len(src)), s.adlen); err != nil {
+		panic(err)
+	}
+	s.startMessage()
+	s.msglen += uint64(len(src))
+
+	if s.n > 0 {
+		k := s.xorPartial(dst, src, decrypt)
+		dst, src = dst[k:], src[k:]
+	}
+
+	full := len(src) &^ 63
+	if decrypt {
+		s.state.dec(dst[:full], src[:full])
+	} else {
+		s.state.enc(dst[:full], src[:full])
+	}
+	dst, src = dst[full:], src[full:]
+
+	if len(src) > 0 {
+		s.computeKeyStream()
+		s.xorPartial(dst, src, decrypt)
+	}
+}
+
+// computeKeyStream sets ks to the keystream of the next block. The keystream
+// of a block only depends on the state before it, so it is taken from a copy,
+// and the block is absorbed once it is complete.
+func (s *stream128x2) computeKeyStream() {
+	var zero [64]byte
+	tmp := s.state
+	tmp.enc(s.ks[:], zero[:])
+}
+
+// xorPartial processes up to the end of the incomplete block in buf, and
+// returns the number of bytes it consumed.
+func (s *stream128x2) xorPartial(dst, src []byte, decrypt bool) int {
+	k := min(64-s.n, len(src))
+	for i := range k {
+		if decrypt {
+			s.buf[s.n+i] = src[i] ^ s.ks[s.n+i]
+			dst[i] = s.buf[s.n+i]
+		} else {
+			s.buf[s.n+i] = src[i]
+			dst[i] = src[i] ^ s.ks[s.n+i]
+		}
+	}
+	s.n += k
+	if s.n == 64 {
+		s.state.absorb(s.buf[:])
+		s.n = 0
+	}
+	return k
+}
+
+// finish absorbs any incomplete block, zero-padded, leaving the state ready to
+// be finalized.
+func (s *stream128x2) finish() {
+	s.checkNotFinished()
+	s.startMessage()
+	s.finished = true
+	if s.n > 0 {
+		clear(s.buf[s.n:])
+		s.state.absorb(s.buf[:])
+		s.n = 0
+	}
+}
+
+// wipe clears everything derived from the key or the plaintext.
+func (s *stream128x2) wipe() {
+	clear(s.key[:])
+	clear(s.state.w[:])
+	clear(s.buf[:])
+	clear(s.ks[:])
+}
+
+func (s *stream128x2) checkNotFinished() {
+	if s.finished {
+		panic("aegis: use of Sealer or Opener after Finish")
+	}
+}
@@ -0,0 +1,244 @@
+package aegis
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// EncryptWriter128x2 and DecryptReader128x2 implement the STREAM construction
+// of Hoang, Reyhanitabar, Rogaway and Vizár on top of AEAD128x2. The stream is
+// cut into segments of StreamSegmentSize128x2 bytes, the last of which may be
+// shorter, and every segment is sealed on its own with the nonce
+//
+//	prefix || counter || last
+//
+// where prefix is StreamNoncePrefixSize128x2 bytes chosen by the caller,
+// counter is the index of the segment as a 32-bit big-endian integer, and last
+// is 1 for the final segment and 0 otherwise. This makes the stream online-AE
+// secure: a DecryptReader128x2 only returns plaintext from segments that
+// authenticate, and reports segments that were reordered, dropped from the
+// end, or added after it.
+
+const (
+	// StreamSegmentSize128x2 is the size of the plaintext of every segment but
+	// the last.
+	StreamSegmentSize128x2 = 64 << 10
+
+	// StreamNoncePrefixSize128x2 is the size of the nonce prefix of a stream.
+	// A prefix must never be used for two streams under the same key, which
+	// random prefixes of this size make unlikely.
+	StreamNoncePrefixSize128x2 = 11
+)
+
+var (
+	errStreamTooLong = errors.New("aegis: stream has too many segments")
+	errStreamClosed  = errors.New("aegis: write to closed EncryptWriter128x2")
+)
+
+// streamNonce returns the nonce of the segment with the given counter.
+func streamNonce(prefix *[StreamNoncePrefixSize128x2]byte, counter uint32, last bool) [16]byte {
+	var nonce [16]byte
+	copy(nonce[:], prefix[:])
+	binary.BigEndian.PutUint32(nonce[StreamNoncePrefixSize128x2:], counter)
+	if last {
+		nonce[15] = 1
+	}
+	return nonce
+}
+
+// EncryptWriter128x2 encrypts a stream into an underlying io.Writer. See
+// NewEncryptWriter.
+type EncryptWriter128x2 struct {
+	a       AEAD128x2
+	w       io.Writer
+	prefix  [StreamNoncePrefixSize128x2]byte
+	counter uint32
+
+	// buf holds the plaintext of the current segment, and has room for its
+	// tag so that it can be sealed in place.
+	buf []byte
+
+	err error
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it
+// into w, as a stream of segments whose nonces start with noncePrefix, which
+// must be StreamNoncePrefixSize128x2 bytes long.
+//
+// Close must be called once all of the plaintext has been written, to write
+// the final segment; without it the stream cannot be decrypted. Close does not
+// close w.
+func (a AEAD128x2) NewEncryptWriter(w io.Writer, noncePrefix []byte) (*EncryptWriter128x2, error) {
+	if len(noncePrefix) != StreamNoncePrefixSize128x2 {
+		return nil, ErrInvalidNonceSize
+	}
+	return &EncryptWriter128x2{
+		a:      a,
+		w:      w,
+		prefix: [StreamNoncePrefixSize128x2]byte(noncePrefix),
+		buf:    make([]byte, 0, StreamSegmentSize128x2+a.Overhead()),
+	}, nil
+}
+
+// Write encrypts p. Segments are only written to the underlying writer once
+// they are complete, and once it is known whether they are the last.
+func (sw *EncryptWriter128x2) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// A full segment is only sealed once more plaintext shows it is
+		// not the last.
+		if len(sw.buf) == StreamSegmentSize128x2 {
+			if err := sw.flush(false); err != nil {
+				return n, err
+			}
+		}
+
+		k := min(len(p), StreamSegmentSize128x2-len(sw.buf))
+		sw.buf = append(sw.buf, p[:k]...)
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close writes the final segment. It does not close the underlying writer.
+func (sw *EncryptWriter128x2) Close() error {
+	if sw.err != nil {
+		if sw.err == errStreamClosed {
+			return nil
+		}
+		return sw.err
+	}
+	if err := sw.flush(true); err != nil {
+		return err
+	}
+	sw.err = errStreamClosed
+	return nil
+}
+
+func (sw *EncryptWriter128x2) flush(last bool) error {
+	nonce := streamNonce(&sw.prefix, sw.counter, last)
+	sealed := sw.a.Seal(sw.buf[:0], nonce[:], sw.buf, nil)
+	if _, err := sw.w.Write(sealed); err != nil {
+		sw.err = err
+		return err
+	}
+	clear(sw.buf[:cap(sw.buf)])
+	sw.buf = sw.buf[:0]
+
+	if !last {
+		if sw.counter == 1<<32-1 {
+			sw.err = errStreamTooLong
+			return sw.err
+		}
+		sw.counter++
+	}
+	return nil
+}
+
+// DecryptReader128x2 decrypts a stream written by an EncryptWriter128x2. See
+// NewDecryptReader.
+type DecryptReader128x2 struct {
+	a       AEAD128x2
+	r       io.Reader
+	prefix  [StreamNoncePrefixSize128x2]byte
+	counter uint32
+
+	// buf holds a sealed segment, followed by one byte of the next segment,
+	// if any, which tells whether the segment is the last. Segments are
+	// opened in place.
+	buf []byte
+	// next is whether buf[0] already holds the first byte of the next
+	// segment.
+	next bool
+
+	// unread is the plaintext of the current segment not yet returned.
+	unread []byte
+	last   bool
+
+	err error
+}
+
+// NewDecryptReader returns a reader that decrypts the stream read from r,
+// whose segment nonces start with noncePrefix, which must be
+// StreamNoncePrefixSize128x2 bytes long.
+//
+// Read only returns plaintext from segments that authenticate. If a segment
+// does not, or if the stream was truncated or extended, Read returns
+// ErrAuthFailed, after the plaintext of the segments before it.
+func (a AEAD128x2) NewDecryptReader(r io.Reader, noncePrefix []byte) (*DecryptReader128x2, error) {
+	if len(noncePrefix) != StreamNoncePrefixSize128x2 {
+		return nil, ErrInvalidNonceSize
+	}
+	return &DecryptReader128x2{
+		a:      a,
+		r:      r,
+		prefix: [StreamNoncePrefixSize128x2]byte(noncePrefix),
+		buf:    make([]byte, StreamSegmentSize128x2+a.Overhead()+1),
+	}, nil
+}
+
+func (sr *DecryptReader128x2) Read(p []byte) (int, error) {
+	for len(sr.unread) == 0 && sr.err == nil {
+		if sr.last {
+			sr.err = io.EOF
+		} else {
+			sr.err = sr.readSegment()
+		}
+	}
+	if len(sr.unread) > 0 {
+		n := copy(p, sr.unread)
+		sr.unread = sr.unread[n:]
+		return n, nil
+	}
+	return 0, sr.err
+}
+
+func (sr *DecryptReader128x2) readSegment() error {
+	segmentSize := StreamSegmentSize128x2 + sr.a.Overhead()
+
+	start := 0
+	if sr.next {
+		sr.buf[0] = sr.buf[segmentSize]
+		start = 1
+	}
+	n, err := io.ReadFull(sr.r, sr.buf[start:])
+	n += start
+	switch err {
+	case nil:
+		// There is at least one more byte, so this segment is not the last.
+		sr.next = true
+		n = segmentSize
+	case io.EOF, io.ErrUnexpectedEOF:
+		sr.last = true
+	default:
+		return err
+	}
+
+	// A final segment too short to hold a tag was cut, and an empty one after
+	// others was not written by an EncryptWriter128x2: either way, the stream
+	// does not authenticate.
+	if sr.last && (n < sr.a.Overhead() || n == sr.a.Overhead() && sr.counter > 0) {
+		return ErrAuthFailed
+	}
+
+	nonce := streamNonce(&sr.prefix, sr.counter, sr.last)
+	plaintext, err := sr.a.Open(sr.buf[:0], nonce[:], sr.buf[:n], nil)
+	if err != nil {
+		return err
+	}
+	sr.unread = plaintext
+
+	if !sr.last {
+		if sr.counter == 1<<32-1 {
+			return errStreamTooLong
+		}
+		sr.counter++
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package aegis_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"testing/iotest"
+
+	"github.com/balasanjay/aegis"
+)
+
+func encryptStream(t *testing.T, aead aegis.AEAD128x2, prefix, plaintext []byte, rng *rand.Rand) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := aead.NewEncryptWriter(&buf, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(plaintext) > 0 {
+		n := min(len(plaintext), rng.IntN(3*aegis.StreamSegmentSize128x2/2))
+		if _, err := w.Write(plaintext[:n]); err != nil {
+			t.Fatal(err)
+		}
+		plaintext = plaintext[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(aead aegis.AEAD128x2, prefix, ciphertext []byte) ([]byte, error) {
+	r, err := aead.NewDecryptReader(bytes.NewReader(ciphertext), prefix)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestAegis128x2StreamIO(t *testing.T) {
+	const seg = aegis.StreamSegmentSize128x2
+	rng := rand.New(rand.NewPCG(41, 42))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+
+	for _, length := range []int{0, 1, seg - 1, seg, seg + 1, 2 * seg, 3*seg + 100} {
+		prefix := randomBytes(rng, aegis.StreamNoncePrefixSize128x2)
+		plaintext := randomBytes(rng, length)
+		ciphertext := encryptStream(t, aead, prefix, plaintext, rng)
+
+		segments := max(1, (length+seg-1)/seg)
+		if want := length + segments*aead.Overhead(); len(ciphertext) != want {
+			t.Errorf("len=%d: ciphertext is %d bytes, want %d", length, len(ciphertext), want)
+		}
+
+		got, err := decryptStream(aead, prefix, ciphertext)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("len=%d: decrypted %d bytes, %v; want %d bytes, nil", length, len(got), err, length)
+		}
+
+		// Each segment is an ordinary AEAD128x2 ciphertext.
+		if length == seg+1 {
+			nonce := append(bytes.Clone(prefix), 0, 0, 0, 1, 1)
+			last, err := aead.Open(nil, nonce, ciphertext[seg+aead.Overhead():], nil)
+			if err != nil || !bytes.Equal(last, plaintext[seg:]) {
+				t.Errorf("opening the last segment directly = %x, %v", last, err)
+			}
+		}
+	}
+}
+
+func TestAegis128x2StreamIOReaders(t *testing.T) {
+	rng := rand.New(rand.NewPCG(43, 44))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	prefix := randomBytes(rng, aegis.StreamNoncePrefixSize128x2)
+	plaintext := randomBytes(rng, aegis.StreamSegmentSize128x2+500)
+	ciphertext := encryptStream(t, aead, prefix, plaintext, rng)
+
+	r, err := aead.NewDecryptReader(iotest.HalfReader(bytes.NewReader(ciphertext)), prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := iotest.TestReader(r, plaintext); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAegis128x2StreamIOTampering(t *testing.T) {
+	const seg = aegis.StreamSegmentSize128x2
+	rng := rand.New(rand.NewPCG(45, 46))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	prefix := randomBytes(rng, aegis.StreamNoncePrefixSize128x2)
+	plaintext := randomBytes(rng, 3*seg+100)
+	ciphertext := encryptStream(t, aead, prefix, plaintext, rng)
+	sealed := seg + aead.Overhead()
+
+	otherPrefix := randomBytes(rng, aegis.StreamNoncePrefixSize128x2)
+	other := encryptStream(t, aead, otherPrefix, randomBytes(rng, 2*seg), rng)
+
+	// emptyFinal is an empty final segment after the first three, which an
+	// EncryptWriter128x2 never writes.
+	emptyFinalNonce := append(slices.Clone(prefix), 0, 0, 0, 3, 1)
+	emptyFinal := aead.Seal(nil, emptyFinalNonce, nil, nil)
+
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	tcs := []struct {
+		name       string
+		ciphertext []byte
+		prefix     []byte
+		// good is how much plaintext comes out before the error.
+		good int
+	}{
+		{"TruncatedAtSegment", ciphertext[:2*sealed], prefix, seg},
+		{"TruncatedAtLastSegment", ciphertext[:3*sealed], prefix, 2 * seg},
+		{"TruncatedAfterSegment", ciphertext[:2*sealed+1], prefix, 2 * seg},
+		{"EmptyFinalSegment", cat(ciphertext[:3*sealed], emptyFinal), prefix, 3 * seg},
+		{"TruncatedInSegment", ciphertext[:2*sealed+10], prefix, 2 * seg},
+		{"TruncatedInTag", ciphertext[:len(ciphertext)-1], prefix, 3 * seg},
+		{"Empty", nil, prefix, 0},
+		{"Reordered", cat(ciphertext[sealed:2*sealed], ciphertext[:sealed], ciphertext[2*sealed:]), prefix, 0},
+		{"Extended", cat(ciphertext, other[:sealed]), prefix, 3 * seg},
+		{"Spliced", cat(ciphertext[:sealed], other[sealed:]), prefix, seg},
+		{"FlippedBit", bytesWithFlippedBit(ciphertext, sealed+7), prefix, seg},
+		{"WrongPrefix", ciphertext, otherPrefix, 0},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decryptStream(aead, tc.prefix, tc.ciphertext)
+			if err == nil {
+				t.Fatal("decryption succeeded")
+			}
+			if !errors.Is(err, aegis.ErrAuthFailed) {
+				t.Errorf("error = %v, want %v", err, aegis.ErrAuthFailed)
+			}
+			if !bytes.Equal(got, plaintext[:tc.good]) {
+				t.Errorf("released %d bytes of plaintext, want the first %d", len(got), tc.good)
+			}
+		})
+	}
+}
+
+func TestAegis128x2StreamIOMisuse(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{})
+	if _, err := aead.NewEncryptWriter(io.Discard, make([]byte, 12)); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("NewEncryptWriter(12-byte prefix) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, err := aead.NewDecryptReader(bytes.NewReader(nil), nil); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("NewDecryptReader(nil prefix) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+
+	w, err := aead.NewEncryptWriter(io.Discard, make([]byte, aegis.StreamNoncePrefixSize128x2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+
+	// Errors from the underlying writer stick.
+	w, err = aead.NewEncryptWriter(errWriter{}, make([]byte, aegis.StreamNoncePrefixSize128x2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, aegis.StreamSegmentSize128x2+1)); err == nil {
+		t.Error("Write to a failing writer succeeded")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close after a failed Write succeeded")
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
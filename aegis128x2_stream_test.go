@@ -0,0 +1,182 @@
+package aegis_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// writeInChunks calls f on consecutive random-length chunks of b.
+func writeInChunks(rng *rand.Rand, b []byte, f func(chunk []byte)) {
+	for len(b) > 0 {
+		n := min(len(b), rng.IntN(150))
+		f(b[:n])
+		b = b[n:]
+	}
+}
+
+func TestAegis128x2Stream(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(7, 8))
+		for range 300 {
+			key := ([16]byte)(randomBytes(rng, 16))
+			nonce := randomBytes(rng, 16)
+			plaintext := randomBytes(rng, rng.IntN(600))
+			aad := randomBytes(rng, rng.IntN(300))
+
+			aead := aegis.NewAEAD128x2(key)
+			wantCiphertext, wantTag16 := aead.DetachedSeal16(nil, nonce, plaintext, aad)
+			_, wantTag32 := aead.DetachedSeal32(nil, nonce, plaintext, aad)
+
+			sealer, err := aead.NewSealer(nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeInChunks(rng, aad, func(chunk []byte) {
+				if err := sealer.WriteAAD(chunk); err != nil {
+					t.Fatal(err)
+				}
+			})
+			ciphertext := make([]byte, len(plaintext))
+			off := 0
+			writeInChunks(rng, plaintext, func(chunk []byte) {
+				sealer.XORKeyStream(ciphertext[off:], chunk)
+				off += len(chunk)
+			})
+			if !bytes.Equal(ciphertext, wantCiphertext) {
+				t.Fatalf("streamed ciphertext = %x, want %x", ciphertext, wantCiphertext)
+			}
+			if tag := sealer.Finish16(); tag != wantTag16 {
+				t.Fatalf("Finish16() = %x, want %x", tag, wantTag16)
+			}
+
+			// Open in place, with the 32-byte tag.
+			opener, err := aead.NewOpener(nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeInChunks(rng, aad, func(chunk []byte) {
+				if err := opener.WriteAAD(chunk); err != nil {
+					t.Fatal(err)
+				}
+			})
+			writeInChunks(rng, ciphertext, func(chunk []byte) {
+				opener.XORKeyStream(chunk, chunk)
+			})
+			if !bytes.Equal(ciphertext, plaintext) {
+				t.Fatalf("streamed plaintext = %x, want %x", ciphertext, plaintext)
+			}
+			if err := opener.Finish32(wantTag32); err != nil {
+				t.Fatalf("Finish32() = %v", err)
+			}
+		}
+	})
+}
+
+func TestAegis128x2StreamVectors(t *testing.T) {
+	for _, tc := range aegis128x2TestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			aead := aegis.NewAEAD128x2(([16]byte)(unhex(tc.key)))
+			sealer, err := aead.NewSealer(unhex(tc.nonce))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Feed the inputs one byte at a time.
+			for _, b := range unhex(tc.additionalData) {
+				if err := sealer.WriteAAD([]byte{b}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var ciphertext []byte
+			for _, b := range unhex(tc.plaintext) {
+				c := []byte{b}
+				sealer.XORKeyStream(c, c)
+				ciphertext = append(ciphertext, c...)
+			}
+			tag := sealer.Finish32()
+
+			if got := hex.EncodeToString(ciphertext); got != tc.expectedCiphertext {
+				t.Errorf("got ciphertext=%q, want ciphertext=%q", got, tc.expectedCiphertext)
+			}
+			if got := hex.EncodeToString(tag[:]); got != tc.expectedTag32 {
+				t.Errorf("got tag=%q, want tag=%q", got, tc.expectedTag32)
+			}
+		})
+	}
+}
+
+func TestAegis128x2StreamOpenerFailure(t *testing.T) {
+	rng := rand.New(rand.NewPCG(9, 10))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	nonce := randomBytes(rng, 16)
+	plaintext := randomBytes(rng, 100)
+	ciphertext, tag := aead.DetachedSeal16(nil, nonce, plaintext, nil)
+	tag[0] ^= 1
+
+	opener, err := aead.NewOpener(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opener.XORKeyStream(ciphertext, ciphertext)
+	if err := opener.Finish16(tag); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Fatalf("Finish16(wrong tag) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+
+	// Nothing may be done with an Opener once its tag has been checked.
+	mustPanic(t, "Finish16 after Finish16", func() { opener.Finish16(tag) })
+	mustPanic(t, "XORKeyStream after Finish16", func() { opener.XORKeyStream(ciphertext, ciphertext) })
+	mustPanic(t, "WriteAAD after Finish16", func() { opener.WriteAAD(nil) })
+}
+
+func TestAegis128x2StreamMisuse(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{})
+
+	if _, err := aead.NewSealer(make([]byte, 15)); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("NewSealer(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, err := aead.NewOpener(make([]byte, 17)); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("NewOpener(long nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+
+	sealer, err := aead.NewSealer(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 10)
+	sealer.XORKeyStream(buf, buf)
+	mustPanic(t, "WriteAAD after XORKeyStream", func() { sealer.WriteAAD(nil) })
+	mustPanic(t, "XORKeyStream into a short buffer", func() { sealer.XORKeyStream(buf[:1], buf) })
+	mustPanic(t, "XORKeyStream with inexact overlap", func() { sealer.XORKeyStream(buf[1:], buf[:5]) })
+	sealer.Finish16()
+	mustPanic(t, "Finish32 after Finish16", func() { sealer.Finish32() })
+}
+
+func TestAegis128x2StreamWriter(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	nonce := randomBytes(rng, 16)
+	plaintext := randomBytes(rng, 1000)
+
+	sealer, err := aead.NewSealer(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ciphertext bytes.Buffer
+	w := cipher.StreamWriter{S: sealer, W: &ciphertext}
+	if _, err := io.Copy(w, bytes.NewReader(plaintext)); err != nil {
+		t.Fatal(err)
+	}
+	tag := sealer.Finish16()
+
+	got, err := aead.Open(nil, nonce, append(ciphertext.Bytes(), tag[:]...), nil)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("Open = %x, %v, want %x, nil", got, err, plaintext)
+	}
+}
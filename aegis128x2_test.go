@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"strconv"
 	"testing"
 
@@ -68,61 +69,137 @@ var aegis128x2TestCases = []struct {
 }
 
 func TestAegis128x2(t *testing.T) {
-	for _, tc := range aegis128x2TestCases {
-		t.Run(tc.name, func(t *testing.T) {
-			key := unhex(tc.key)
-			nonce := unhex(tc.nonce)
+	forEachImplementation(t, func(t *testing.T) {
+		for _, tc := range aegis128x2TestCases {
+			t.Run(tc.name, func(t *testing.T) {
+				key := unhex(tc.key)
+				nonce := unhex(tc.nonce)
+
+				aead := aegis.NewAEAD128x2(([16]byte)(key))
+
+				{
+
+					ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+					gotCiphertext := hex.EncodeToString(ciphertext)
+					if gotCiphertext != tc.expectedCiphertext {
+						t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+					}
+
+					gotTag := hex.EncodeToString(tag[:])
+					if gotTag != tc.expectedTag16 {
+						t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+					}
+
+					rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+					gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+					if err != nil {
+						t.Errorf("got unexpected error: %v", err)
+					}
+					if gotRtPlaintext != tc.plaintext {
+						t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+					}
+				}
 
-			aead := aegis.NewAEAD128x2(([16]byte)(key))
+				{
 
-			{
+					ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
 
-				ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+					gotCiphertext := hex.EncodeToString(ciphertext)
+					if gotCiphertext != tc.expectedCiphertext {
+						t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+					}
 
-				gotCiphertext := hex.EncodeToString(ciphertext)
-				if gotCiphertext != tc.expectedCiphertext {
-					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
-				}
+					gotTag := hex.EncodeToString(tag[:])
+					if gotTag != tc.expectedTag32 {
+						t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
+					}
 
-				gotTag := hex.EncodeToString(tag[:])
-				if gotTag != tc.expectedTag16 {
-					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+					rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+					gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+					if err != nil {
+						t.Errorf("got unexpected error: %v", err)
+					}
+					if gotRtPlaintext != tc.plaintext {
+						t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+					}
 				}
+			})
+		}
+	})
+}
 
-				rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
-				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
-				if err != nil {
-					t.Errorf("got unexpected error: %v", err)
-				}
-				if gotRtPlaintext != tc.plaintext {
-					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
-				}
+func TestAegis128x2WithTagSize(t *testing.T) {
+	for _, tagSize := range []int{16, 32} {
+		t.Run(strconv.Itoa(tagSize), func(t *testing.T) {
+			for _, tc := range aegis128x2TestCases {
+				t.Run(tc.name, func(t *testing.T) {
+					aead, err := aegis.NewAEAD128x2WithTagSize(([16]byte)(unhex(tc.key)), tagSize)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got := aead.Overhead(); got != tagSize {
+						t.Fatalf("got Overhead()=%d, want %d", got, tagSize)
+					}
+
+					expectedTag := tc.expectedTag16
+					if tagSize == 32 {
+						expectedTag = tc.expectedTag32
+					}
+
+					nonce := unhex(tc.nonce)
+					sealed := aead.Seal(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+					gotSealed := hex.EncodeToString(sealed)
+					if gotSealed != tc.expectedCiphertext+expectedTag {
+						t.Errorf("got sealed=%q, want sealed=%q", gotSealed, tc.expectedCiphertext+expectedTag)
+					}
+
+					rtPlaintext, err := aead.Open(nil, nonce, sealed, unhex(tc.additionalData))
+					if err != nil {
+						t.Errorf("got unexpected error: %v", err)
+					}
+					if got := hex.EncodeToString(rtPlaintext); got != tc.plaintext {
+						t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", got, tc.plaintext)
+					}
+
+					if _, err := aead.Open(nil, nonce, bytesWithFlippedBit(sealed, len(sealed)-1), unhex(tc.additionalData)); err == nil {
+						t.Errorf("Open succeeded with a modified tag")
+					}
+				})
 			}
+		})
+	}
 
-			{
-
-				ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+	for _, tagSize := range []int{-1, 0, 8, 15, 17, 24, 33, 64} {
+		if _, err := aegis.NewAEAD128x2WithTagSize([16]byte{}, tagSize); !errors.Is(err, aegis.ErrInvalidTagSize) {
+			t.Errorf("NewAEAD128x2WithTagSize(key, %d) = %v, want %v", tagSize, err, aegis.ErrInvalidTagSize)
+		}
+	}
+}
 
-				gotCiphertext := hex.EncodeToString(ciphertext)
-				if gotCiphertext != tc.expectedCiphertext {
-					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
-				}
+func TestAegis128x2TryDetachedSeal(t *testing.T) {
+	tc := aegis128x2TestCases[len(aegis128x2TestCases)-1]
+	aead := aegis.NewAEAD128x2(([16]byte)(unhex(tc.key)))
+	nonce := unhex(tc.nonce)
 
-				gotTag := hex.EncodeToString(tag[:])
-				if gotTag != tc.expectedTag32 {
-					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
-				}
+	ciphertext, tag16, err := aead.TryDetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+	if err != nil || hex.EncodeToString(ciphertext) != tc.expectedCiphertext || hex.EncodeToString(tag16[:]) != tc.expectedTag16 {
+		t.Errorf("TryDetachedSeal16 = %x, %x, %v", ciphertext, tag16, err)
+	}
+	ciphertext, tag32, err := aead.TryDetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+	if err != nil || hex.EncodeToString(ciphertext) != tc.expectedCiphertext || hex.EncodeToString(tag32[:]) != tc.expectedTag32 {
+		t.Errorf("TryDetachedSeal32 = %x, %x, %v", ciphertext, tag32, err)
+	}
 
-				rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
-				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
-				if err != nil {
-					t.Errorf("got unexpected error: %v", err)
-				}
-				if gotRtPlaintext != tc.plaintext {
-					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
-				}
-			}
-		})
+	if _, _, err := aead.TryDetachedSeal16(nil, nonce[1:], nil, nil); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("TryDetachedSeal16(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, _, err := aead.TryDetachedSeal32(nil, nonce[1:], nil, nil); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("TryDetachedSeal32(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	if _, err := aead.DetachedOpen32(nil, nonce[1:], nil, nil, [32]byte{}); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("DetachedOpen32(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
 	}
 }
 
@@ -198,6 +275,54 @@ func BenchmarkAegis128x2(b *testing.B) {
 	}
 }
 
+// BenchmarkAegis128x2Batch compares sealing a batch of short messages one at a
+// time with SealBatch.
+func BenchmarkAegis128x2Batch(b *testing.B) {
+	const batch = 64
+	for _, length := range []int{16, 100, 1024} {
+		var key [16]byte
+		aead := aegis.NewAEAD128x2(key)
+		nonces := make([][]byte, batch)
+		plaintexts := make([][]byte, batch)
+		sealed := make([][]byte, batch)
+		for i := range batch {
+			nonces[i] = make([]byte, 16)
+			plaintexts[i] = make([]byte, length)
+			sealed[i] = make([]byte, 0, length+16)
+		}
+
+		b.Run(strconv.Itoa(length)+"/Seal", func(b *testing.B) {
+			b.SetBytes(int64(batch * length))
+			for b.Loop() {
+				for i := range batch {
+					sealed[i] = aead.Seal(sealed[i][:0], nonces[i], plaintexts[i], nil)
+				}
+			}
+		})
+		b.Run(strconv.Itoa(length)+"/SealBatch", func(b *testing.B) {
+			b.SetBytes(int64(batch * length))
+			for b.Loop() {
+				for i := range batch {
+					sealed[i] = sealed[i][:0]
+				}
+				aead.SealBatch(sealed, nonces, plaintexts, nil)
+			}
+		})
+		b.Run(strconv.Itoa(length)+"/OpenBatch", func(b *testing.B) {
+			opened := make([][]byte, batch)
+			b.SetBytes(int64(batch * length))
+			for b.Loop() {
+				for i := range batch {
+					opened[i] = plaintexts[i][:0]
+				}
+				if errs := aead.OpenBatch(opened, nonces, sealed, nil); errs != nil {
+					b.Fatal(errs)
+				}
+			}
+		})
+	}
+}
+
 func TestAegisMac128x2(t *testing.T) {
 	tcs := []struct {
 		name string
@@ -226,33 +351,35 @@ func TestAegisMac128x2(t *testing.T) {
 		},
 	}
 
-	for _, tc := range tcs {
-		t.Run(tc.name, func(t *testing.T) {
-			key := unhex(tc.key)
-			nonce := unhex(tc.nonce)
+	forEachImplementation(t, func(t *testing.T) {
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				key := unhex(tc.key)
+				nonce := unhex(tc.nonce)
 
-			mac := aegis.NewMac128x2(([16]byte)(key))
+				mac := aegis.NewMac128x2(([16]byte)(key))
 
-			{
+				{
 
-				tag := mac.Sum16(nonce, unhex(tc.data))
+					tag := mac.Sum16(nonce, unhex(tc.data))
 
-				gotTag := hex.EncodeToString(tag[:])
-				if gotTag != tc.expectedTag16 {
-					t.Errorf("got tag16=%q, want tag=%q", gotTag, tc.expectedTag16)
+					gotTag := hex.EncodeToString(tag[:])
+					if gotTag != tc.expectedTag16 {
+						t.Errorf("got tag16=%q, want tag=%q", gotTag, tc.expectedTag16)
+					}
 				}
-			}
 
-			{
-				tag := mac.Sum32(nonce, unhex(tc.data))
+				{
+					tag := mac.Sum32(nonce, unhex(tc.data))
 
-				gotTag := hex.EncodeToString(tag[:])
-				if gotTag != tc.expectedTag32 {
-					t.Errorf("got tag32=%q, want tag=%q", gotTag, tc.expectedTag32)
+					gotTag := hex.EncodeToString(tag[:])
+					if gotTag != tc.expectedTag32 {
+						t.Errorf("got tag32=%q, want tag=%q", gotTag, tc.expectedTag32)
+					}
 				}
-			}
-		})
-	}
+			})
+		}
+	})
 }
 
 func benchmarkAegisMac128x2(b *testing.B, data []byte) {
@@ -276,6 +403,38 @@ func BenchmarkAegisMac128x2(b *testing.B) {
 	}
 }
 
+// BenchmarkAegisMac128x2Batch compares computing the tags of a batch of short
+// messages one at a time with Sum16Batch.
+func BenchmarkAegisMac128x2Batch(b *testing.B) {
+	const batch = 64
+	for _, length := range []int{16, 100, 1024} {
+		var key [16]byte
+		mac := aegis.NewMac128x2(key)
+		nonces := make([][]byte, batch)
+		data := make([][]byte, batch)
+		for i := range batch {
+			nonces[i] = make([]byte, 16)
+			data[i] = make([]byte, length)
+		}
+		tags := make([][16]byte, batch)
+
+		b.Run(strconv.Itoa(length)+"/Sum16", func(b *testing.B) {
+			b.SetBytes(int64(batch * length))
+			for b.Loop() {
+				for i := range batch {
+					tags[i] = mac.Sum16(nonces[i], data[i])
+				}
+			}
+		})
+		b.Run(strconv.Itoa(length)+"/Sum16Batch", func(b *testing.B) {
+			b.SetBytes(int64(batch * length))
+			for b.Loop() {
+				mac.Sum16Batch(tags, nonces, data)
+			}
+		})
+	}
+}
+
 func unhex(h string) []byte {
 	b, err := hex.DecodeString(h)
 	if err != nil {
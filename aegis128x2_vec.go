@@ -0,0 +1,154 @@
+package aegis
+
+import (
+	"crypto/subtle"
+)
+
+// The vectored APIs below take their inputs as lists of fragments, such as
+// net.Buffers, and behave exactly as if the fragments had been concatenated
+// first. Only the 64-byte blocks that straddle fragments are copied.
+
+// vecLen returns the total length of the fragments of v.
+func vecLen(v [][]byte) uint64 {
+	var n uint64
+	for _, b := range v {
+		n += uint64(len(b))
+	}
+	return n
+}
+
+// checkVecAliasing is checkAliasing for fragments: each fragment of in may
+// start exactly where its part of out starts, but must not otherwise overlap
+// out, and no fragment of aad may overlap out at all.
+func checkVecAliasing(out []byte, in, aad [][]byte) {
+	off := 0
+	for _, b := range in {
+		if anyOverlap(out, b) && (off >= len(out) || &out[off] != &b[0]) {
+			panic("aegis: invalid buffer overlap of output and input")
+		}
+		off += len(b)
+	}
+	for _, b := range aad {
+		checkAliasing(out, nil, b)
+	}
+}
+
+// SealVec is like Seal, with the plaintext and additional data given as
+// fragments. The result is the same as sealing their concatenations.
+func (a AEAD128x2) SealVec(dst, nonce []byte, plaintext, additionalData [][]byte) []byte {
+	msglen, adlen := vecLen(plaintext), vecLen(additionalData)
+	if err := checkArgs(nonce, a.NonceSize(), msglen, adlen); err != nil {
+		panic(err)
+	}
+
+	ret, out := sliceForAppend(dst, int(msglen)+a.Overhead())
+	checkVecAliasing(out[:msglen], plaintext, additionalData)
+
+	var s stream128x2
+	s.init(&a.key, (*[16]byte)(nonce))
+	for _, b := range additionalData {
+		s.writeAAD(b)
+	}
+	off := 0
+	for _, b := range plaintext {
+		s.xorKeyStream(out[off:], b, false)
+		off += len(b)
+	}
+
+	s.finish()
+	tag := s.state.finalize16(s.adlen, s.msglen)
+	s.wipe()
+
+	copy(out[off:], tag[:])
+	return ret
+}
+
+// OpenVec is like Open, with the ciphertext and additional data given as
+// fragments. The tag may be split across the last fragments of ciphertext.
+func (a AEAD128x2) OpenVec(dst, nonce []byte, ciphertext, additionalData [][]byte) ([]byte, error) {
+	total, adlen := vecLen(ciphertext), vecLen(additionalData)
+	if total < uint64(a.Overhead()) {
+		return nil, ErrCiphertextTooShort
+	}
+	msglen := total - uint64(a.Overhead())
+	if err := checkArgs(nonce, a.NonceSize(), msglen, adlen); err != nil {
+		return nil, err
+	}
+
+	ret, out := sliceForAppend(dst, int(msglen))
+	checkVecAliasing(out, ciphertext, additionalData)
+
+	// Split the fragments into the message and the tag, before decrypting
+	// anything, since the tag may sit right after the output.
+	var tag [16]byte
+	var message [][]byte
+	{
+		n := int(msglen)
+		for i, b := range ciphertext {
+			if len(b) > n {
+				message = ciphertext[:i:i]
+				if n > 0 {
+					message = append(message, b[:n])
+				}
+				t := copy(tag[:], b[n:])
+				for _, b := range ciphertext[i+1:] {
+					t += copy(tag[t:], b)
+				}
+				break
+			}
+			n -= len(b)
+		}
+	}
+
+	var s stream128x2
+	s.init(&a.key, (*[16]byte)(nonce))
+	for _, b := range additionalData {
+		s.writeAAD(b)
+	}
+	off := 0
+	for _, b := range message {
+		s.xorKeyStream(out[off:], b, true)
+		off += len(b)
+	}
+
+	s.finish()
+	expectedTag := s.state.finalize16(s.adlen, s.msglen)
+	s.wipe()
+
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+// Sum16Vec is like Sum16, with the data given as fragments.
+func (m Mac128x2) Sum16Vec(nonce []byte, data [][]byte) [16]byte {
+	var s stream128x2
+	m.absorbVec(&s, nonce, data)
+	tag := s.state.finalizeMac16(s.adlen)
+	s.wipe()
+	return tag
+}
+
+// Sum32Vec is like Sum32, with the data given as fragments.
+func (m Mac128x2) Sum32Vec(nonce []byte, data [][]byte) [32]byte {
+	var s stream128x2
+	m.absorbVec(&s, nonce, data)
+	tag := s.state.finalizeMac32(s.adlen)
+	s.wipe()
+	return tag
+}
+
+// absorbVec sets s to a state that has absorbed data, ready to be finalized.
+func (m Mac128x2) absorbVec(s *stream128x2, nonce []byte, data [][]byte) {
+	if err := checkArgs(nonce, 16, 0, vecLen(data)); err != nil {
+		panic(err)
+	}
+
+	s.init(&m.key, (*[16]byte)(nonce))
+	for _, b := range data {
+		s.writeAAD(b)
+	}
+	s.finish()
+}
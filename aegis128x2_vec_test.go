@@ -0,0 +1,118 @@
+package aegis_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// fragment splits b into random fragments, some of them empty.
+func fragment(rng *rand.Rand, b []byte) [][]byte {
+	var v [][]byte
+	writeInChunks(rng, b, func(chunk []byte) { v = append(v, chunk) })
+	if rng.IntN(2) == 0 {
+		v = append(v, nil)
+	}
+	return v
+}
+
+func TestAegis128x2Vec(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(35, 36))
+		for range 200 {
+			aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+			nonce := randomBytes(rng, 16)
+			plaintext := randomBytes(rng, rng.IntN(500))
+			aad := randomBytes(rng, rng.IntN(200))
+			want := aead.Seal([]byte("prefix"), nonce, plaintext, aad)
+
+			got := aead.SealVec([]byte("prefix"), nonce, fragment(rng, plaintext), fragment(rng, aad))
+			if !bytes.Equal(got, want) {
+				t.Fatalf("SealVec() = %x, want %x", got, want)
+			}
+
+			// The tag may be split across fragments.
+			opened, err := aead.OpenVec(nil, nonce, fragment(rng, want[len("prefix"):]), fragment(rng, aad))
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Fatalf("OpenVec() = %x, %v, want %x, nil", opened, err, plaintext)
+			}
+
+			mac := aegis.NewMac128x2(([16]byte)(randomBytes(rng, 16)))
+			if got, want := mac.Sum16Vec(nonce, fragment(rng, aad)), mac.Sum16(nonce, aad); got != want {
+				t.Fatalf("Sum16Vec() = %x, want %x", got, want)
+			}
+			if got, want := mac.Sum32Vec(nonce, fragment(rng, aad)), mac.Sum32(nonce, aad); got != want {
+				t.Fatalf("Sum32Vec() = %x, want %x", got, want)
+			}
+		}
+	})
+}
+
+func TestAegis128x2VecNetBuffers(t *testing.T) {
+	aead := aegis.NewAEAD128x2([16]byte{1})
+	nonce := make([]byte, 16)
+	payload := net.Buffers{[]byte("header:"), []byte("body"), []byte(":trailer")}
+	sealed := aead.SealVec(nil, nonce, payload, nil)
+
+	want := aead.Seal(nil, nonce, []byte("header:body:trailer"), nil)
+	if !bytes.Equal(sealed, want) {
+		t.Fatalf("SealVec(net.Buffers) = %x, want %x", sealed, want)
+	}
+}
+
+func TestAegis128x2VecInPlace(t *testing.T) {
+	rng := rand.New(rand.NewPCG(37, 38))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	nonce := randomBytes(rng, 16)
+	plaintext := randomBytes(rng, 300)
+	want := aead.Seal(nil, nonce, plaintext, nil)
+
+	// Fragments that sit exactly where their output goes may be sealed and
+	// opened in place.
+	buf := make([]byte, len(plaintext), len(plaintext)+16)
+	copy(buf, plaintext)
+	got := aead.SealVec(buf[:0], nonce, [][]byte{buf[:100], buf[100:101], buf[101:]}, nil)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("in-place SealVec() = %x, want %x", got, want)
+	}
+	opened, err := aead.OpenVec(got[:0], nonce, [][]byte{got[:64], got[64:310], got[310:]}, nil)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("in-place OpenVec() = %x, %v, want %x, nil", opened, err, plaintext)
+	}
+
+	// Anything else that overlaps the output panics.
+	copy(buf, plaintext)
+	mustPanic(t, "SealVec with shifted fragments", func() {
+		aead.SealVec(buf[:0], nonce, [][]byte{buf[1:101], buf[:1], buf[101:]}, nil)
+	})
+	mustPanic(t, "SealVec with additional data in the output", func() {
+		aead.SealVec(buf[:0], nonce, [][]byte{plaintext}, [][]byte{buf[10:20]})
+	})
+}
+
+func TestAegis128x2OpenVecErrors(t *testing.T) {
+	rng := rand.New(rand.NewPCG(39, 40))
+	aead := aegis.NewAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+	nonce := randomBytes(rng, 16)
+	sealed := aead.Seal(nil, nonce, randomBytes(rng, 100), []byte("aad"))
+
+	for i := range sealed {
+		if _, err := aead.OpenVec(nil, nonce, fragment(rng, bytesWithFlippedBit(sealed, i)), [][]byte{[]byte("aad")}); !errors.Is(err, aegis.ErrAuthFailed) {
+			t.Fatalf("OpenVec(byte %d flipped) = %v, want %v", i, err, aegis.ErrAuthFailed)
+		}
+	}
+	if _, err := aead.OpenVec(nil, nonce, [][]byte{sealed}, [][]byte{[]byte("aa"), []byte("d!")}); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("OpenVec(wrong additional data) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+	if _, err := aead.OpenVec(nil, nonce, [][]byte{sealed[:10], sealed[10:15]}, nil); !errors.Is(err, aegis.ErrCiphertextTooShort) {
+		t.Errorf("OpenVec(15 bytes) = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+	if _, err := aead.OpenVec(nil, nonce[:8], [][]byte{sealed}, nil); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+		t.Errorf("OpenVec(short nonce) = %v, want %v", err, aegis.ErrInvalidNonceSize)
+	}
+	mustPanic(t, "SealVec with a short nonce", func() { aead.SealVec(nil, nonce[:8], nil, nil) })
+}
@@ -0,0 +1,89 @@
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+)
+
+// XAEAD128x2 is AEGIS-128X2 with a 32-byte nonce, long enough to be drawn at
+// random for any number of messages under one key, in the spirit of
+// XChaCha20-Poly1305.
+//
+// Each message is sealed with AEAD128x2 under a subkey, which is the 16-byte
+// tag Mac128x2 computes under the key, with the first 16 bytes of the nonce as
+// its nonce, over the ASCII string "XAEAD128x2". The last 16 bytes of the nonce
+// are the nonce of AEAD128x2. Mac128x2 acts as a pseudorandom function of the
+// first half of the nonce, so messages only share both a subkey and an
+// AEAD128x2 nonce if their whole 32-byte nonces are equal.
+//
+// The key of an XAEAD128x2 must not also be used with AEAD128x2 or Mac128x2
+// directly.
+type XAEAD128x2 struct {
+	key [16]byte
+}
+
+var _ cipher.AEAD = XAEAD128x2{}
+
+// xaead128x2Label is the data over which Mac128x2 computes subkeys.
+const xaead128x2Label = "XAEAD128x2"
+
+func NewXAEAD128x2(key [16]byte) XAEAD128x2 {
+	return XAEAD128x2{key}
+}
+
+func (a XAEAD128x2) NonceSize() int {
+	return 32
+}
+
+func (a XAEAD128x2) Overhead() int {
+	return 16
+}
+
+// subAEAD returns the AEAD128x2 that seals messages under nonce, which must be
+// 32 bytes long.
+func (a XAEAD128x2) subAEAD(nonce []byte) AEAD128x2 {
+	subkey := NewMac128x2(a.key).Sum16(nonce[:16], []byte(xaead128x2Label))
+	return NewAEAD128x2(subkey)
+}
+
+func (a XAEAD128x2) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	if len(nonce) != a.NonceSize() {
+		panic(ErrInvalidNonceSize)
+	}
+	return a.subAEAD(nonce).Seal(dst, nonce[16:], plaintext, aad)
+}
+
+func (a XAEAD128x2) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(nonce) != a.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	return a.subAEAD(nonce).Open(dst, nonce[16:], ciphertext, aad)
+}
+
+// SealWithRandomNonce seals plaintext like Seal, under a 32-byte nonce drawn
+// from crypto/rand, and appends that nonce followed by the ciphertext and tag
+// to dst. Use OpenWithPrefixedNonce to open the result. Unlike
+// AEAD128x2.SealWithRandomNonce, there is no practical limit on the number of
+// messages sealed this way under one key.
+//
+// To seal in place, put the plaintext at buf[32:] and use buf[:0] as dst.
+func (a XAEAD128x2) SealWithRandomNonce(dst, plaintext, aad []byte) []byte {
+	ret, out := sliceForAppend(dst, 32+len(plaintext)+a.Overhead())
+	checkPrefixedAliasing(out, 32, plaintext, aad)
+
+	rand.Read(out[:32])
+	a.Seal(out[:32], out[:32], plaintext, aad)
+	return ret
+}
+
+// OpenWithPrefixedNonce opens a message sealed by SealWithRandomNonce, whose
+// first 32 bytes are its nonce, and appends the plaintext to dst.
+//
+// To reuse ciphertext's storage for the plaintext, use ciphertext[32:32] as
+// dst.
+func (a XAEAD128x2) OpenWithPrefixedNonce(dst, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < 32+a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	return a.Open(dst, ciphertext[:32], ciphertext[32:], aad)
+}
@@ -0,0 +1,133 @@
+package aegis_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+type xaead128x2Vector struct {
+	Key        string `json:"key"`
+	Nonce      string `json:"nonce"`
+	Plaintext  string `json:"plaintext"`
+	AD         string `json:"ad"`
+	Subkey     string `json:"subkey"`
+	Ciphertext string `json:"ciphertext"`
+	Tag        string `json:"tag"`
+}
+
+func loadXAEAD128x2Vectors(t *testing.T) []xaead128x2Vector {
+	t.Helper()
+	data, err := os.ReadFile("testdata/xaead128x2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Vectors []xaead128x2Vector `json:"vectors"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Vectors) == 0 {
+		t.Fatal("no test vectors")
+	}
+	return file.Vectors
+}
+
+func TestXAEAD128x2(t *testing.T) {
+	vectors := loadXAEAD128x2Vectors(t)
+	forEachImplementation(t, func(t *testing.T) {
+		for i, v := range vectors {
+			key, nonce := ([16]byte)(unhex(v.Key)), unhex(v.Nonce)
+			plaintext, ad := unhex(v.Plaintext), unhex(v.AD)
+
+			// The subkey, and the AEAD128x2 ciphertext under it, follow the
+			// construction.
+			subkey := aegis.NewMac128x2(key).Sum16(nonce[:16], []byte("XAEAD128x2"))
+			if got := hex.EncodeToString(subkey[:]); got != v.Subkey {
+				t.Errorf("vector %d: subkey = %s, want %s", i, got, v.Subkey)
+			}
+			want := aegis.NewAEAD128x2(subkey).Seal(nil, nonce[16:], plaintext, ad)
+			if got := hex.EncodeToString(want); got != v.Ciphertext+v.Tag {
+				t.Errorf("vector %d: AEAD128x2 under the subkey = %s, want %s", i, got, v.Ciphertext+v.Tag)
+			}
+
+			aead := aegis.NewXAEAD128x2(key)
+			sealed := aead.Seal(nil, nonce, plaintext, ad)
+			if got := hex.EncodeToString(sealed); got != v.Ciphertext+v.Tag {
+				t.Errorf("vector %d: Seal() = %s, want %s", i, got, v.Ciphertext+v.Tag)
+			}
+			got, err := aead.Open(nil, nonce, sealed, ad)
+			if err != nil || !bytes.Equal(got, plaintext) {
+				t.Errorf("vector %d: Open() = %x, %v", i, got, err)
+			}
+
+			for _, idx := range []int{0, len(sealed) - 1} {
+				if _, err := aead.Open(nil, nonce, bytesWithFlippedBit(sealed, idx), ad); err != aegis.ErrAuthFailed {
+					t.Errorf("vector %d: flipped byte %d: err = %v, want %v", i, idx, err, aegis.ErrAuthFailed)
+				}
+			}
+			for _, idx := range []int{0, 15, 16, 31} {
+				if _, err := aead.Open(nil, bytesWithFlippedBit(nonce, idx), sealed, ad); err != aegis.ErrAuthFailed {
+					t.Errorf("vector %d: flipped nonce byte %d: err = %v, want %v", i, idx, err, aegis.ErrAuthFailed)
+				}
+			}
+		}
+	})
+}
+
+func TestXAEAD128x2Errors(t *testing.T) {
+	aead := aegis.NewXAEAD128x2([16]byte{})
+	if aead.NonceSize() != 32 || aead.Overhead() != 16 {
+		t.Fatalf("NonceSize() = %d, Overhead() = %d; want 32, 16", aead.NonceSize(), aead.Overhead())
+	}
+	for _, n := range []int{0, 16, 31, 33} {
+		mustPanic(t, "Seal with a short nonce", func() { aead.Seal(nil, make([]byte, n), nil, nil) })
+		if _, err := aead.Open(nil, make([]byte, n), make([]byte, 16), nil); err != aegis.ErrInvalidNonceSize {
+			t.Errorf("Open with a %d-byte nonce: err = %v, want %v", n, err, aegis.ErrInvalidNonceSize)
+		}
+	}
+	if _, err := aead.Open(nil, make([]byte, 32), make([]byte, 15), nil); err != aegis.ErrCiphertextTooShort {
+		t.Errorf("short ciphertext: err = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+	if _, err := aead.OpenWithPrefixedNonce(nil, make([]byte, 47), nil); err != aegis.ErrCiphertextTooShort {
+		t.Errorf("short prefixed ciphertext: err = %v, want %v", err, aegis.ErrCiphertextTooShort)
+	}
+}
+
+func TestXAEAD128x2RandomNonce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(91, 92))
+	aead := aegis.NewXAEAD128x2(([16]byte)(randomBytes(rng, 16)))
+
+	for _, length := range []int{0, 1, 64, 1000} {
+		plaintext, ad := randomBytes(rng, length), randomBytes(rng, 7)
+		sealed := aead.SealWithRandomNonce(nil, plaintext, ad)
+		if len(sealed) != 32+length+16 {
+			t.Fatalf("len=%d: SealWithRandomNonce returned %d bytes", length, len(sealed))
+		}
+		if want := aead.Seal(nil, sealed[:32], plaintext, ad); !bytes.Equal(sealed[32:], want) {
+			t.Errorf("len=%d: output is not the nonce followed by Seal's output", length)
+		}
+		got, err := aead.OpenWithPrefixedNonce(sealed[32:32], sealed, ad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("len=%d: OpenWithPrefixedNonce() = %x, %v", length, got, err)
+		}
+
+		// Sealing in place, with the plaintext after room for the nonce.
+		buf := make([]byte, 32+length, 32+length+16)
+		copy(buf[32:], plaintext)
+		sealed = aead.SealWithRandomNonce(buf[:0], buf[32:], ad)
+		if &sealed[0] != &buf[0] || !bytes.Equal(sealed[32:], aead.Seal(nil, sealed[:32], plaintext, ad)) {
+			t.Errorf("len=%d: in-place SealWithRandomNonce() is wrong", length)
+		}
+	}
+
+	buf := make([]byte, 100)
+	mustPanic(t, "SealWithRandomNonce over the plaintext", func() { aead.SealWithRandomNonce(buf[:0], buf[16:50], nil) })
+	mustPanic(t, "SealWithRandomNonce with the nonce over the plaintext", func() { aead.SealWithRandomNonce(buf[:0], buf[8:10], nil) })
+}
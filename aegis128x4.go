@@ -0,0 +1,308 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"simd/archsimd"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+type AEAD128x4 struct {
+	key [16]byte
+
+	// ok is set by NewAEAD128x4. The zero value has skipped its CPU check, so
+	// it must not reach the AVX-512 kernels.
+	ok bool
+}
+
+var _ cipher.AEAD = AEAD128x4{}
+
+func NewAEAD128x4(key [16]byte) (AEAD128x4, error) {
+	if active < implVAES512 {
+		return AEAD128x4{}, errNoAVX512VAES
+	}
+	return AEAD128x4{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that a was created by NewAEAD128x4.
+func (a AEAD128x4) checkArgs(nonce []byte, msglen, adlen uint64) error {
+	if !a.ok {
+		return errNoAVX512VAES
+	}
+	return checkArgs(nonce, a.NonceSize(), msglen, adlen)
+}
+
+func (a AEAD128x4) NonceSize() int {
+	return 16
+}
+
+func (a AEAD128x4) Overhead() int {
+	return 16
+}
+
+func (a AEAD128x4) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	_, tag := a.DetachedSeal16(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func absorbAad128x4(state impl.State128x4, aad []byte) impl.State128x4 {
+	var i int
+	for i = 0; i+128 <= len(aad); i += 128 {
+		m0 := archsimd.LoadUint8x64Slice(aad[i : i+64])
+		m1 := archsimd.LoadUint8x64Slice(aad[i+64 : i+128])
+		state = impl.UpdateState128x4(state, m0, m1)
+	}
+
+	if i < len(aad) {
+		var last [128]byte
+		copy(last[:], aad[i:])
+
+		m0 := archsimd.LoadUint8x64Slice(last[0:64])
+		m1 := archsimd.LoadUint8x64Slice(last[64:128])
+		state = impl.UpdateState128x4(state, m0, m1)
+	}
+
+	return state
+}
+
+func (a AEAD128x4) detachedSeal(dst, nonce, plaintext, aad []byte) ([]byte, impl.State128x4) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		panic(err)
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext))
+	checkAliasing(out, plaintext, aad)
+
+	state := impl.InitState128x4(archsimd.LoadUint8x16(&a.key), archsimd.LoadUint8x16Slice(nonce))
+	state = absorbAad128x4(state, aad)
+
+	// Encrypt blocks.
+	{
+		var i int
+		for i = 0; i+128 <= len(plaintext); i += 128 {
+			p0 := archsimd.LoadUint8x64Slice(plaintext[i : i+64])
+			p1 := archsimd.LoadUint8x64Slice(plaintext[i+64 : i+128])
+
+			var c0, c1 archsimd.Uint8x64
+			state, c0, c1 = impl.Enc128x4(state, p0, p1)
+
+			c0.StoreSlice(out[i : i+64])
+			c1.StoreSlice(out[i+64 : i+128])
+		}
+
+		if i < len(plaintext) {
+			var last [128]byte
+			copy(last[:], plaintext[i:])
+
+			p0 := archsimd.LoadUint8x64Slice(last[0:64])
+			p1 := archsimd.LoadUint8x64Slice(last[64:128])
+
+			var c0, c1 archsimd.Uint8x64
+			state, c0, c1 = impl.Enc128x4(state, p0, p1)
+
+			c0.StoreSlice(last[0:64])
+			c1.StoreSlice(last[64:128])
+
+			copy(out[i:len(plaintext)], last[:])
+		}
+	}
+
+	return ret, state
+}
+
+func (a AEAD128x4) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize128x4_16(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+func (a AEAD128x4) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize128x4_32(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+// TrySeal is like AEAD128x2.TrySeal. It also fails if a was not created by
+// NewAEAD128x4.
+func (a AEAD128x4) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+	return a.Seal(dst, nonce, plaintext, aad), nil
+}
+
+// TryDetachedSeal16 is like AEAD128x2.TryDetachedSeal16.
+func (a AEAD128x4) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [16]byte{}, err
+	}
+	ret, tag := a.DetachedSeal16(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+// TryDetachedSeal32 is like AEAD128x2.TryDetachedSeal32.
+func (a AEAD128x4) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [32]byte{}, err
+	}
+	ret, tag := a.DetachedSeal32(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+func (a AEAD128x4) detachedOpen(dst, nonce, ciphertext, aad []byte) ([]byte, []byte, impl.State128x4) {
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	checkAliasing(out, ciphertext, aad)
+
+	state := impl.InitState128x4(archsimd.LoadUint8x16(&a.key), archsimd.LoadUint8x16Slice(nonce))
+	state = absorbAad128x4(state, aad)
+
+	// Decrypt blocks.
+	{
+		var i int
+		for i = 0; i+128 <= len(ciphertext); i += 128 {
+			c0 := archsimd.LoadUint8x64Slice(ciphertext[i : i+64])
+			c1 := archsimd.LoadUint8x64Slice(ciphertext[i+64 : i+128])
+
+			var p0, p1 archsimd.Uint8x64
+			state, p0, p1 = impl.Dec128x4(state, c0, c1)
+
+			p0.StoreSlice(out[i : i+64])
+			p1.StoreSlice(out[i+64 : i+128])
+		}
+
+		if i < len(ciphertext) {
+			var last [128]byte
+			copy(last[:], ciphertext[i:])
+
+			state, last = impl.DecPartial128x4(state, last, len(ciphertext)-i)
+
+			copy(out[i:len(ciphertext)], last[:len(ciphertext)-i])
+		}
+	}
+
+	return ret, out, state
+}
+
+func (a AEAD128x4) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize128x4_16(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD128x4) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize128x4_32(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD128x4) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	checkAliasing(out, ciphertext, aad)
+
+	tag := ([16]byte)(ciphertext[len(ciphertext)-a.Overhead():])
+	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
+
+	if _, err := a.DetachedOpen16(out[:0], nonce, ciphertext, aad, tag); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type Mac128x4 struct {
+	key [16]byte
+
+	// ok is set by NewMac128x4. The zero value has skipped its CPU check, so
+	// it must not reach the AVX-512 kernels.
+	ok bool
+}
+
+func NewMac128x4(key [16]byte) (Mac128x4, error) {
+	if active < implVAES512 {
+		return Mac128x4{}, errNoAVX512VAES
+	}
+	return Mac128x4{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that m was created by NewMac128x4.
+func (m Mac128x4) checkArgs(nonce []byte, datalen uint64) error {
+	if !m.ok {
+		return errNoAVX512VAES
+	}
+	return checkArgs(nonce, 16, 0, datalen)
+}
+
+func (m Mac128x4) Sum16(nonce []byte, data []byte) [16]byte {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	state := impl.InitState128x4(archsimd.LoadUint8x16(&m.key), archsimd.LoadUint8x16Slice(nonce))
+	state = absorbAad128x4(state, data)
+	return impl.Finalize128x4Mac_16(state, uint64(len(data)))
+}
+
+func (m Mac128x4) Sum32(nonce []byte, data []byte) [32]byte {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	state := impl.InitState128x4(archsimd.LoadUint8x16(&m.key), archsimd.LoadUint8x16Slice(nonce))
+	state = absorbAad128x4(state, data)
+	return impl.Finalize128x4Mac_32(state, uint64(len(data)))
+}
+
+// Verify16 is like Mac128x2.Verify16.
+func (m Mac128x4) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum16(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// Verify32 is like Mac128x2.Verify32.
+func (m Mac128x4) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum32(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
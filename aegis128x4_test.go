@@ -0,0 +1,218 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+var aegis128x4TestCases = []struct {
+	name string
+
+	// Inputs (all hex-encoded)
+	key            string
+	nonce          string
+	plaintext      string
+	additionalData string
+
+	// Expected outputs (all hex-encoded).
+	expectedCiphertext string
+	expectedTag16      string
+	expectedTag32      string
+}{
+	{
+		name: "TestVector1",
+
+		key:            "000102030405060708090a0b0c0d0e0f",
+		nonce:          "101112131415161718191a1b1c1d1e1f",
+		plaintext:      "",
+		additionalData: "",
+
+		expectedCiphertext: "",
+		expectedTag16:      "5bef762d0947c00455b97bb3af30dfa3",
+		expectedTag32: "a4b25437f4be93cfa856a2f27e4416b4" +
+			"2cac79fd4698f2cdbe6af25673e10a68",
+	},
+
+	{
+		name: "TestVector2",
+
+		key:   "000102030405060708090a0b0c0d0e0f",
+		nonce: "101112131415161718191a1b1c1d1e1f",
+		plaintext: "04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"0405060704050607",
+		additionalData: "0102030401020304",
+
+		expectedCiphertext: "e836118562f4479c9d35c17356a83311" +
+			"4c21f9aa39e4dda5e5c87f4152a00fce" +
+			"9a7c38f832eafe8b1c12f8a7cf12a81a" +
+			"1ad8a9c24ba9dedfbdaa586ffea67ddc" +
+			"801ea97d9ab4a872f42d0e352e2713da" +
+			"cd609f9442c17517c5a29daf3e2a3fac" +
+			"4ff6b1380c4e46df7b086af6ce6bc1ed" +
+			"594b8dd64aed2a7e",
+		expectedTag16: "0e56ab94e2e85db80f9d54010caabfb4",
+		expectedTag32: "69abf0f64a137dd6e122478d777e98bc" +
+			"422823006cf57f5ee822dd78397230b2",
+	},
+}
+
+func newAEAD128x4(t testing.TB, key [16]byte) aegis.AEAD128x4 {
+	aead, err := aegis.NewAEAD128x4(key)
+	if err != nil {
+		t.Skipf("AEGIS-128X4 unavailable: %v", err)
+	}
+	return aead
+}
+
+func TestAegis128x4(t *testing.T) {
+	for _, tc := range aegis128x4TestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			aead := newAEAD128x4(t, ([16]byte)(key))
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkAegis128x4(b *testing.B, plaintext []byte) {
+	var key [16]byte
+	var nonce [16]byte
+	ciphertext := make([]byte, len(plaintext)+16)
+
+	b.SetBytes(int64(len(plaintext)))
+
+	aead := newAEAD128x4(b, key)
+
+	for b.Loop() {
+		ciphertext = ciphertext[:0]
+		ciphertext = aead.Seal(ciphertext, nonce[:], plaintext, nil)
+	}
+}
+
+func BenchmarkAegis128x4(b *testing.B) {
+	for _, length := range []int{64, 16384, 65536} {
+		b.Run(strconv.Itoa(length), func(b *testing.B) {
+			benchmarkAegis128x4(b, make([]byte, length))
+		})
+	}
+}
+
+func TestAegisMac128x4(t *testing.T) {
+	tcs := []struct {
+		name string
+
+		// Inputs (all hex-encoded)
+		key   string
+		nonce string
+		data  string
+
+		// Expected outputs (all hex-encoded).
+		expectedTag16 string
+		expectedTag32 string
+	}{
+		{
+			name: "TestVector1",
+
+			key:   "10010000000000000000000000000000",
+			nonce: "10000200000000000000000000000000",
+			data: "000102030405060708090a0b0c0d0e0f" +
+				"101112131415161718191a1b1c1d1e1f" +
+				"202122",
+
+			expectedTag16: "c45a98fd9ab8956ce616eb008cfe4e53",
+			expectedTag32: "26fdc76f41b1da7aec7779f6e964beae" +
+				"8904e662f05aca8345ae3befb357412a",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			mac, err := aegis.NewMac128x4(([16]byte)(key))
+			if err != nil {
+				t.Skipf("AEGIS-128X4 unavailable: %v", err)
+			}
+
+			{
+
+				tag := mac.Sum16(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag16=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+			}
+
+			{
+				tag := mac.Sum32(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag32=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+			}
+		})
+	}
+}
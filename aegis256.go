@@ -0,0 +1,309 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"simd/archsimd"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+type AEAD256 struct {
+	key [32]byte
+
+	// ok is set by NewAEAD256. The zero value has skipped its CPU check, so
+	// it must not reach the kernels.
+	ok bool
+}
+
+var _ cipher.AEAD = AEAD256{}
+
+func NewAEAD256(key [32]byte) (AEAD256, error) {
+	if active < implAESNI {
+		return AEAD256{}, errNoAESNI
+	}
+	return AEAD256{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that a was created by NewAEAD256.
+func (a AEAD256) checkArgs(nonce []byte, msglen, adlen uint64) error {
+	if !a.ok {
+		return errNoAESNI
+	}
+	return checkArgs(nonce, a.NonceSize(), msglen, adlen)
+}
+
+func (a AEAD256) NonceSize() int {
+	return 32
+}
+
+func (a AEAD256) Overhead() int {
+	return 16
+}
+
+func (a AEAD256) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	_, tag := a.DetachedSeal16(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func initState256(key *[32]byte, nonce []byte) impl.State256 {
+	return impl.InitState256(
+		archsimd.LoadUint8x16Slice(key[0:16]),
+		archsimd.LoadUint8x16Slice(key[16:32]),
+		archsimd.LoadUint8x16Slice(nonce[0:16]),
+		archsimd.LoadUint8x16Slice(nonce[16:32]),
+	)
+}
+
+func absorbAad256(state impl.State256, aad []byte) impl.State256 {
+	var i int
+	for i = 0; i+16 <= len(aad); i += 16 {
+		m := archsimd.LoadUint8x16Slice(aad[i : i+16])
+		state = impl.UpdateState256(state, m)
+	}
+
+	if i < len(aad) {
+		var last [16]byte
+		copy(last[:], aad[i:])
+
+		m := archsimd.LoadUint8x16(&last)
+		state = impl.UpdateState256(state, m)
+	}
+
+	return state
+}
+
+func (a AEAD256) detachedSeal(dst, nonce, plaintext, aad []byte) ([]byte, impl.State256) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		panic(err)
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext))
+	checkAliasing(out, plaintext, aad)
+
+	state := initState256(&a.key, nonce)
+	state = absorbAad256(state, aad)
+
+	// Encrypt blocks.
+	{
+		var i int
+		for i = 0; i+16 <= len(plaintext); i += 16 {
+			p := archsimd.LoadUint8x16Slice(plaintext[i : i+16])
+
+			var c archsimd.Uint8x16
+			state, c = impl.Enc256(state, p)
+
+			c.StoreSlice(out[i : i+16])
+		}
+
+		if i < len(plaintext) {
+			var last [16]byte
+			copy(last[:], plaintext[i:])
+
+			p := archsimd.LoadUint8x16(&last)
+
+			var c archsimd.Uint8x16
+			state, c = impl.Enc256(state, p)
+
+			c.Store(&last)
+
+			copy(out[i:len(plaintext)], last[:])
+		}
+	}
+
+	return ret, state
+}
+
+func (a AEAD256) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize256_16(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+func (a AEAD256) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize256_32(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+// TrySeal is like AEAD128x2.TrySeal. It also fails if a was not created by
+// NewAEAD256.
+func (a AEAD256) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+	return a.Seal(dst, nonce, plaintext, aad), nil
+}
+
+// TryDetachedSeal16 is like AEAD128x2.TryDetachedSeal16.
+func (a AEAD256) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [16]byte{}, err
+	}
+	ret, tag := a.DetachedSeal16(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+// TryDetachedSeal32 is like AEAD128x2.TryDetachedSeal32.
+func (a AEAD256) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [32]byte{}, err
+	}
+	ret, tag := a.DetachedSeal32(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+func (a AEAD256) detachedOpen(dst, nonce, ciphertext, aad []byte) ([]byte, []byte, impl.State256) {
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	checkAliasing(out, ciphertext, aad)
+
+	state := initState256(&a.key, nonce)
+	state = absorbAad256(state, aad)
+
+	// Decrypt blocks.
+	{
+		var i int
+		for i = 0; i+16 <= len(ciphertext); i += 16 {
+			c := archsimd.LoadUint8x16Slice(ciphertext[i : i+16])
+
+			var p archsimd.Uint8x16
+			state, p = impl.Dec256(state, c)
+
+			p.StoreSlice(out[i : i+16])
+		}
+
+		if i < len(ciphertext) {
+			var last [16]byte
+			copy(last[:], ciphertext[i:])
+
+			state, last = impl.DecPartial256(state, last, len(ciphertext)-i)
+
+			copy(out[i:len(ciphertext)], last[:len(ciphertext)-i])
+		}
+	}
+
+	return ret, out, state
+}
+
+func (a AEAD256) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize256_16(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD256) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize256_32(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD256) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	checkAliasing(out, ciphertext, aad)
+
+	tag := ([16]byte)(ciphertext[len(ciphertext)-a.Overhead():])
+	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
+
+	if _, err := a.DetachedOpen16(out[:0], nonce, ciphertext, aad, tag); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type Mac256 struct {
+	key [32]byte
+
+	// ok is set by NewMac256. The zero value has skipped its CPU check, so
+	// it must not reach the kernels.
+	ok bool
+}
+
+func NewMac256(key [32]byte) (Mac256, error) {
+	if active < implAESNI {
+		return Mac256{}, errNoAESNI
+	}
+	return Mac256{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that m was created by NewMac256.
+func (m Mac256) checkArgs(nonce []byte, datalen uint64) error {
+	if !m.ok {
+		return errNoAESNI
+	}
+	return checkArgs(nonce, 32, 0, datalen)
+}
+
+func (m Mac256) Sum16(nonce []byte, data []byte) [16]byte {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	state := initState256(&m.key, nonce)
+	state = absorbAad256(state, data)
+	return impl.Finalize256Mac_16(state, uint64(len(data)))
+}
+
+func (m Mac256) Sum32(nonce []byte, data []byte) [32]byte {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	state := initState256(&m.key, nonce)
+	state = absorbAad256(state, data)
+	return impl.Finalize256Mac_32(state, uint64(len(data)))
+}
+
+// Verify16 is like Mac128x2.Verify16.
+func (m Mac256) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum16(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// Verify32 is like Mac128x2.Verify32.
+func (m Mac256) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum32(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+import "crypto/cipher"
+
+// AEAD256 needs the SIMD kernels, so without them it is only declared, to keep
+// the package API the same on every platform: NewAEAD256 always fails, and
+// every method fails as it does for the zero value.
+type AEAD256 struct{}
+
+var _ cipher.AEAD = AEAD256{}
+
+func NewAEAD256(key [32]byte) (AEAD256, error) {
+	return AEAD256{}, errNoAESNI
+}
+
+func (a AEAD256) NonceSize() int {
+	return 32
+}
+
+func (a AEAD256) Overhead() int {
+	return 16
+}
+
+func (a AEAD256) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	panic(errNoAESNI)
+}
+
+func (a AEAD256) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	panic(errNoAESNI)
+}
+
+func (a AEAD256) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	panic(errNoAESNI)
+}
+
+func (a AEAD256) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+func (a AEAD256) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	return nil, [16]byte{}, errNoAESNI
+}
+
+func (a AEAD256) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	return nil, [32]byte{}, errNoAESNI
+}
+
+func (a AEAD256) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+func (a AEAD256) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+func (a AEAD256) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	return nil, errNoAESNI
+}
+
+// Mac256 is only declared, like AEAD256.
+type Mac256 struct{}
+
+func NewMac256(key [32]byte) (Mac256, error) {
+	return Mac256{}, errNoAESNI
+}
+
+func (m Mac256) Sum16(nonce []byte, data []byte) [16]byte {
+	panic(errNoAESNI)
+}
+
+func (m Mac256) Sum32(nonce []byte, data []byte) [32]byte {
+	panic(errNoAESNI)
+}
+
+func (m Mac256) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	return errNoAESNI
+}
+
+func (m Mac256) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	return errNoAESNI
+}
@@ -0,0 +1,271 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+var aegis256TestCases = []struct {
+	name string
+
+	// Inputs (all hex-encoded)
+	key            string
+	nonce          string
+	plaintext      string
+	additionalData string
+
+	// Expected outputs (all hex-encoded).
+	expectedCiphertext string
+	expectedTag16      string
+	expectedTag32      string
+}{
+	{
+		name: "TestVector1",
+
+		key: "10010000000000000000000000000000" +
+			"00000000000000000000000000000000",
+		nonce: "10000200000000000000000000000000" +
+			"00000000000000000000000000000000",
+		plaintext:      "00000000000000000000000000000000",
+		additionalData: "",
+
+		expectedCiphertext: "754fc3d8c973246dcc6d741412a4b236",
+		expectedTag16:      "3fe91994768b332ed7f570a19ec5896e",
+		expectedTag32: "1181a1d18091082bf0266f66297d167d" +
+			"2e68b845f61a3b0527d31fc7b7b89f13",
+	},
+
+	{
+		name: "TestVector2",
+
+		key: "10010000000000000000000000000000" +
+			"00000000000000000000000000000000",
+		nonce: "10000200000000000000000000000000" +
+			"00000000000000000000000000000000",
+		plaintext:      "",
+		additionalData: "",
+
+		expectedCiphertext: "",
+		expectedTag16:      "e3def978a0f054afd1e761d7553afba3",
+		expectedTag32: "6a348c930adbd654896e1666aad67de9" +
+			"89ea75ebaa2b82fb588977b1ffec864a",
+	},
+
+	{
+		name: "TestVector3",
+
+		key: "10010000000000000000000000000000" +
+			"00000000000000000000000000000000",
+		nonce: "10000200000000000000000000000000" +
+			"00000000000000000000000000000000",
+		plaintext: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f",
+		additionalData: "0001020304050607",
+
+		expectedCiphertext: "f373079ed84b2709faee373584585d60" +
+			"accd191db310ef5d8b11833df9dec711",
+		expectedTag16: "8d86f91ee606e9ff26a01b64ccbdd91d",
+		expectedTag32: "b7d28d0c3c0ebd409fd22b4416050307" +
+			"3a547412da0854bfb9723020dab8da1a",
+	},
+
+	{
+		name: "TestVector4",
+
+		key: "10010000000000000000000000000000" +
+			"00000000000000000000000000000000",
+		nonce: "10000200000000000000000000000000" +
+			"00000000000000000000000000000000",
+		plaintext:      "000102030405060708090a0b0c0d",
+		additionalData: "0001020304050607",
+
+		expectedCiphertext: "f373079ed84b2709faee37358458",
+		expectedTag16:      "c60b9c2d33ceb058f96e6dd03c215652",
+		expectedTag32: "8c1cc703c81281bee3f6d9966e14948b" +
+			"4a175b2efbdc31e61a98b4465235c2d9",
+	},
+
+	{
+		name: "TestVector5",
+
+		key: "10010000000000000000000000000000" +
+			"00000000000000000000000000000000",
+		nonce: "10000200000000000000000000000000" +
+			"00000000000000000000000000000000",
+		plaintext: "101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f" +
+			"3031323334353637",
+		additionalData: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f" +
+			"20212223242526272829",
+
+		expectedCiphertext: "57754a7d09963e7c787583a2e7b859bb" +
+			"24fa1e04d49fd550b2511a358e3bca25" +
+			"2a9b1b8b30cc4a67",
+		expectedTag16: "ab8a7d53fd0e98d727accca94925e128",
+		expectedTag32: "a3aca270c006094d71c20e6910b5161c" +
+			"0826df233d08919a566ec2c05990f734",
+	},
+}
+
+func TestAegis256(t *testing.T) {
+	for _, tc := range aegis256TestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			aead := newAEAD256(t, ([32]byte)(key))
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkAegis256(b *testing.B, plaintext []byte) {
+	var key [32]byte
+	var nonce [32]byte
+	ciphertext := make([]byte, len(plaintext)+16)
+
+	b.SetBytes(int64(len(plaintext)))
+
+	aead := newAEAD256(b, key)
+
+	for b.Loop() {
+		ciphertext = ciphertext[:0]
+		ciphertext = aead.Seal(ciphertext, nonce[:], plaintext, nil)
+	}
+}
+
+func BenchmarkAegis256(b *testing.B) {
+	for _, length := range []int{64, 16384, 65536} {
+		b.Run(strconv.Itoa(length), func(b *testing.B) {
+			benchmarkAegis256(b, make([]byte, length))
+		})
+	}
+}
+
+func TestAegisMac256(t *testing.T) {
+	tcs := []struct {
+		name string
+
+		// Inputs (all hex-encoded)
+		key   string
+		nonce string
+		data  string
+
+		// Expected outputs (all hex-encoded).
+		expectedTag16 string
+		expectedTag32 string
+	}{
+		{
+			name: "TestVector1",
+
+			key: "10010000000000000000000000000000" +
+				"00000000000000000000000000000000",
+			nonce: "10000200000000000000000000000000" +
+				"00000000000000000000000000000000",
+			data: "000102030405060708090a0b0c0d0e0f" +
+				"101112131415161718191a1b1c1d1e1f" +
+				"202122",
+
+			expectedTag16: "c08e20cfc56f27195a46c9cef5c162d4",
+			expectedTag32: "a5c906ede3d69545c11e20afa360b221" +
+				"f936e946ed2dba3d7c75ad6dc2784126",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			mac := newMac256(t, ([32]byte)(key))
+
+			{
+
+				tag := mac.Sum16(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag16=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+			}
+
+			{
+				tag := mac.Sum32(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag32=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+			}
+		})
+	}
+}
+
+func newAEAD256(t testing.TB, key [32]byte) aegis.AEAD256 {
+	aead, err := aegis.NewAEAD256(key)
+	if err != nil {
+		t.Skipf("AEGIS-256 unavailable: %v", err)
+	}
+	return aead
+}
+
+func newMac256(t testing.TB, key [32]byte) aegis.Mac256 {
+	mac, err := aegis.NewMac256(key)
+	if err != nil {
+		t.Skipf("AEGIS-256 unavailable: %v", err)
+	}
+	return mac
+}
@@ -0,0 +1,309 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"simd/archsimd"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+type AEAD256x2 struct {
+	key [32]byte
+
+	// ok is set by NewAEAD256x2. The zero value has skipped its CPU check, so
+	// it must not reach the kernels.
+	ok bool
+}
+
+var _ cipher.AEAD = AEAD256x2{}
+
+func NewAEAD256x2(key [32]byte) (AEAD256x2, error) {
+	if active < implVAES256 {
+		return AEAD256x2{}, errNoVAES
+	}
+	return AEAD256x2{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that a was created by NewAEAD256x2.
+func (a AEAD256x2) checkArgs(nonce []byte, msglen, adlen uint64) error {
+	if !a.ok {
+		return errNoVAES
+	}
+	return checkArgs(nonce, a.NonceSize(), msglen, adlen)
+}
+
+func (a AEAD256x2) NonceSize() int {
+	return 32
+}
+
+func (a AEAD256x2) Overhead() int {
+	return 16
+}
+
+func (a AEAD256x2) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	checkAliasing(out, plaintext, aad)
+
+	_, tag := a.DetachedSeal16(out[:0], nonce, plaintext, aad)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func initState256x2(key *[32]byte, nonce []byte) impl.State256x2 {
+	return impl.InitState256x2(
+		archsimd.LoadUint8x16Slice(key[0:16]),
+		archsimd.LoadUint8x16Slice(key[16:32]),
+		archsimd.LoadUint8x16Slice(nonce[0:16]),
+		archsimd.LoadUint8x16Slice(nonce[16:32]),
+	)
+}
+
+func absorbAad256x2(state impl.State256x2, aad []byte) impl.State256x2 {
+	var i int
+	for i = 0; i+32 <= len(aad); i += 32 {
+		m := archsimd.LoadUint8x32Slice(aad[i : i+32])
+		state = impl.UpdateState256x2(state, m)
+	}
+
+	if i < len(aad) {
+		var last [32]byte
+		copy(last[:], aad[i:])
+
+		m := archsimd.LoadUint8x32(&last)
+		state = impl.UpdateState256x2(state, m)
+	}
+
+	return state
+}
+
+func (a AEAD256x2) detachedSeal(dst, nonce, plaintext, aad []byte) ([]byte, impl.State256x2) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		panic(err)
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext))
+	checkAliasing(out, plaintext, aad)
+
+	state := initState256x2(&a.key, nonce)
+	state = absorbAad256x2(state, aad)
+
+	// Encrypt blocks.
+	{
+		var i int
+		for i = 0; i+32 <= len(plaintext); i += 32 {
+			p := archsimd.LoadUint8x32Slice(plaintext[i : i+32])
+
+			var c archsimd.Uint8x32
+			state, c = impl.Enc256x2(state, p)
+
+			c.StoreSlice(out[i : i+32])
+		}
+
+		if i < len(plaintext) {
+			var last [32]byte
+			copy(last[:], plaintext[i:])
+
+			p := archsimd.LoadUint8x32(&last)
+
+			var c archsimd.Uint8x32
+			state, c = impl.Enc256x2(state, p)
+
+			c.Store(&last)
+
+			copy(out[i:len(plaintext)], last[:])
+		}
+	}
+
+	return ret, state
+}
+
+func (a AEAD256x2) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize256x2_16(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+func (a AEAD256x2) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	ret, state := a.detachedSeal(dst, nonce, plaintext, aad)
+	tag := impl.Finalize256x2_32(state, uint64(len(aad)), uint64(len(plaintext)))
+	return ret, tag
+}
+
+// TrySeal is like AEAD128x2.TrySeal. It also fails if a was not created by
+// NewAEAD256x2.
+func (a AEAD256x2) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+	return a.Seal(dst, nonce, plaintext, aad), nil
+}
+
+// TryDetachedSeal16 is like AEAD128x2.TryDetachedSeal16.
+func (a AEAD256x2) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [16]byte{}, err
+	}
+	ret, tag := a.DetachedSeal16(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+// TryDetachedSeal32 is like AEAD128x2.TryDetachedSeal32.
+func (a AEAD256x2) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(plaintext)), uint64(len(aad))); err != nil {
+		return nil, [32]byte{}, err
+	}
+	ret, tag := a.DetachedSeal32(dst, nonce, plaintext, aad)
+	return ret, tag, nil
+}
+
+func (a AEAD256x2) detachedOpen(dst, nonce, ciphertext, aad []byte) ([]byte, []byte, impl.State256x2) {
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	checkAliasing(out, ciphertext, aad)
+
+	state := initState256x2(&a.key, nonce)
+	state = absorbAad256x2(state, aad)
+
+	// Decrypt blocks.
+	{
+		var i int
+		for i = 0; i+32 <= len(ciphertext); i += 32 {
+			c := archsimd.LoadUint8x32Slice(ciphertext[i : i+32])
+
+			var p archsimd.Uint8x32
+			state, p = impl.Dec256x2(state, c)
+
+			p.StoreSlice(out[i : i+32])
+		}
+
+		if i < len(ciphertext) {
+			var last [32]byte
+			copy(last[:], ciphertext[i:])
+
+			state, last = impl.DecPartial256x2(state, last, len(ciphertext)-i)
+
+			copy(out[i:len(ciphertext)], last[:len(ciphertext)-i])
+		}
+	}
+
+	return ret, out, state
+}
+
+func (a AEAD256x2) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize256x2_16(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD256x2) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	if err := a.checkArgs(nonce, uint64(len(ciphertext)), uint64(len(aad))); err != nil {
+		return nil, err
+	}
+
+	ret, out, state := a.detachedOpen(dst, nonce, ciphertext, aad)
+
+	expectedTag := impl.Finalize256x2_32(state, uint64(len(aad)), uint64(len(ciphertext)))
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(out)
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+func (a AEAD256x2) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	checkAliasing(out, ciphertext, aad)
+
+	tag := ([16]byte)(ciphertext[len(ciphertext)-a.Overhead():])
+	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
+
+	if _, err := a.DetachedOpen16(out[:0], nonce, ciphertext, aad, tag); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type Mac256x2 struct {
+	key [32]byte
+
+	// ok is set by NewMac256x2. The zero value has skipped its CPU check, so
+	// it must not reach the kernels.
+	ok bool
+}
+
+func NewMac256x2(key [32]byte) (Mac256x2, error) {
+	if active < implVAES256 {
+		return Mac256x2{}, errNoVAES
+	}
+	return Mac256x2{key, true}, nil
+}
+
+// checkArgs is checkArgs, after checking that m was created by NewMac256x2.
+func (m Mac256x2) checkArgs(nonce []byte, datalen uint64) error {
+	if !m.ok {
+		return errNoVAES
+	}
+	return checkArgs(nonce, 32, 0, datalen)
+}
+
+func (m Mac256x2) Sum16(nonce []byte, data []byte) [16]byte {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	state := initState256x2(&m.key, nonce)
+	state = absorbAad256x2(state, data)
+	return impl.Finalize256x2Mac_16(state, uint64(len(data)))
+}
+
+func (m Mac256x2) Sum32(nonce []byte, data []byte) [32]byte {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	state := initState256x2(&m.key, nonce)
+	state = absorbAad256x2(state, data)
+	return impl.Finalize256x2Mac_32(state, uint64(len(data)))
+}
+
+// Verify16 is like Mac128x2.Verify16.
+func (m Mac256x2) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum16(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// Verify32 is like Mac128x2.Verify32.
+func (m Mac256x2) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	if err := m.checkArgs(nonce, uint64(len(data))); err != nil {
+		return err
+	}
+
+	expectedTag := m.Sum32(nonce, data)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		return ErrAuthFailed
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+import "crypto/cipher"
+
+// AEAD256x2 needs the SIMD kernels, so without them it is only declared, to keep
+// the package API the same on every platform: NewAEAD256x2 always fails, and
+// every method fails as it does for the zero value.
+type AEAD256x2 struct{}
+
+var _ cipher.AEAD = AEAD256x2{}
+
+func NewAEAD256x2(key [32]byte) (AEAD256x2, error) {
+	return AEAD256x2{}, errNoVAES
+}
+
+func (a AEAD256x2) NonceSize() int {
+	return 32
+}
+
+func (a AEAD256x2) Overhead() int {
+	return 16
+}
+
+func (a AEAD256x2) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	panic(errNoVAES)
+}
+
+func (a AEAD256x2) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	panic(errNoVAES)
+}
+
+func (a AEAD256x2) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	panic(errNoVAES)
+}
+
+func (a AEAD256x2) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	return nil, errNoVAES
+}
+
+func (a AEAD256x2) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	return nil, [16]byte{}, errNoVAES
+}
+
+func (a AEAD256x2) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	return nil, [32]byte{}, errNoVAES
+}
+
+func (a AEAD256x2) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	return nil, errNoVAES
+}
+
+func (a AEAD256x2) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	return nil, errNoVAES
+}
+
+func (a AEAD256x2) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	return nil, errNoVAES
+}
+
+// Mac256x2 is only declared, like AEAD256x2.
+type Mac256x2 struct{}
+
+func NewMac256x2(key [32]byte) (Mac256x2, error) {
+	return Mac256x2{}, errNoVAES
+}
+
+func (m Mac256x2) Sum16(nonce []byte, data []byte) [16]byte {
+	panic(errNoVAES)
+}
+
+func (m Mac256x2) Sum32(nonce []byte, data []byte) [32]byte {
+	panic(errNoVAES)
+}
+
+func (m Mac256x2) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	return errNoVAES
+}
+
+func (m Mac256x2) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	return errNoVAES
+}
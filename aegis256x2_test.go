@@ -0,0 +1,229 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+var aegis256x2TestCases = []struct {
+	name string
+
+	// Inputs (all hex-encoded)
+	key            string
+	nonce          string
+	plaintext      string
+	additionalData string
+
+	// Expected outputs (all hex-encoded).
+	expectedCiphertext string
+	expectedTag16      string
+	expectedTag32      string
+}{
+	{
+		name: "TestVector1",
+
+		key: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f",
+		nonce: "101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f",
+		plaintext:      "",
+		additionalData: "",
+
+		expectedCiphertext: "",
+		expectedTag16:      "62cdbab084c83dacdb945bb446f049c8",
+		expectedTag32: "25d7e799b49a80354c3f881ac2f1027f" +
+			"471a5d293052bd9997abd3ae84014bb7",
+	},
+
+	{
+		name: "TestVector2",
+
+		key: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f",
+		nonce: "101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f",
+		plaintext: "04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"0405060704050607",
+		additionalData: "0102030401020304",
+
+		expectedCiphertext: "72120c2ea8236180d67859001f472907" +
+			"7b7064c414384fe3a7b52f1571f4f8a7" +
+			"d0f01e18db4f3bc0adb150702e5d147a" +
+			"8d36522132761b994c1bd395589e2ccf" +
+			"0790dfe2a3d12d61cd666b2859827739" +
+			"db4037dd3124c78424459376f6cac08e" +
+			"1a7223a2a43e398ce6385cd654a19f48" +
+			"1cba3b8f25910b42",
+		expectedTag16: "635d391828520bf1512763f0c8f5cdbd",
+		expectedTag32: "b5668d3317159e9cc5d46e4803c3a76a" +
+			"d63bb42b3f47956d94f30db8cb366ad7",
+	},
+}
+
+func TestAegis256x2(t *testing.T) {
+	for _, tc := range aegis256x2TestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			aead := newAEAD256x2(t, ([32]byte)(key))
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkAegis256x2(b *testing.B, plaintext []byte) {
+	var key [32]byte
+	var nonce [32]byte
+	ciphertext := make([]byte, len(plaintext)+16)
+
+	b.SetBytes(int64(len(plaintext)))
+
+	aead := newAEAD256x2(b, key)
+
+	for b.Loop() {
+		ciphertext = ciphertext[:0]
+		ciphertext = aead.Seal(ciphertext, nonce[:], plaintext, nil)
+	}
+}
+
+func BenchmarkAegis256x2(b *testing.B) {
+	for _, length := range []int{64, 16384, 65536} {
+		b.Run(strconv.Itoa(length), func(b *testing.B) {
+			benchmarkAegis256x2(b, make([]byte, length))
+		})
+	}
+}
+
+func TestAegisMac256x2(t *testing.T) {
+	tcs := []struct {
+		name string
+
+		// Inputs (all hex-encoded)
+		key   string
+		nonce string
+		data  string
+
+		// Expected outputs (all hex-encoded).
+		expectedTag16 string
+		expectedTag32 string
+	}{
+		{
+			name: "TestVector1",
+
+			key: "10010000000000000000000000000000" +
+				"00000000000000000000000000000000",
+			nonce: "10000200000000000000000000000000" +
+				"00000000000000000000000000000000",
+			data: "000102030405060708090a0b0c0d0e0f" +
+				"101112131415161718191a1b1c1d1e1f" +
+				"202122",
+
+			expectedTag16: "5e4b59458ad124eb62f2e12afed52d7f",
+			expectedTag32: "0844b20ed5147ceae89c7a160263afd4" +
+				"b1382d6b154ecf560ce8a342cb6a8fd1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			mac := newMac256x2(t, ([32]byte)(key))
+
+			{
+
+				tag := mac.Sum16(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag16=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+			}
+
+			{
+				tag := mac.Sum32(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag32=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+			}
+		})
+	}
+}
+
+func newAEAD256x2(t testing.TB, key [32]byte) aegis.AEAD256x2 {
+	aead, err := aegis.NewAEAD256x2(key)
+	if err != nil {
+		t.Skipf("AEGIS-256X2 unavailable: %v", err)
+	}
+	return aead
+}
+
+func newMac256x2(t testing.TB, key [32]byte) aegis.Mac256x2 {
+	mac, err := aegis.NewMac256x2(key)
+	if err != nil {
+		t.Skipf("AEGIS-256X2 unavailable: %v", err)
+	}
+	return mac
+}
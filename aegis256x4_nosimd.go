@@ -0,0 +1,83 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+import "crypto/cipher"
+
+// AEAD256x4 needs the SIMD kernels, so without them it is only declared, to keep
+// the package API the same on every platform: NewAEAD256x4 always fails, and
+// every method fails as it does for the zero value.
+type AEAD256x4 struct{}
+
+var _ cipher.AEAD = AEAD256x4{}
+
+func NewAEAD256x4(key [32]byte) (AEAD256x4, error) {
+	return AEAD256x4{}, errNoAVX512VAES
+}
+
+func (a AEAD256x4) NonceSize() int {
+	return 32
+}
+
+func (a AEAD256x4) Overhead() int {
+	return 16
+}
+
+func (a AEAD256x4) Seal(dst, nonce, plaintext, aad []byte) []byte {
+	panic(errNoAVX512VAES)
+}
+
+func (a AEAD256x4) DetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte) {
+	panic(errNoAVX512VAES)
+}
+
+func (a AEAD256x4) DetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte) {
+	panic(errNoAVX512VAES)
+}
+
+func (a AEAD256x4) TrySeal(dst, nonce, plaintext, aad []byte) ([]byte, error) {
+	return nil, errNoAVX512VAES
+}
+
+func (a AEAD256x4) TryDetachedSeal16(dst, nonce, plaintext, aad []byte) ([]byte, [16]byte, error) {
+	return nil, [16]byte{}, errNoAVX512VAES
+}
+
+func (a AEAD256x4) TryDetachedSeal32(dst, nonce, plaintext, aad []byte) ([]byte, [32]byte, error) {
+	return nil, [32]byte{}, errNoAVX512VAES
+}
+
+func (a AEAD256x4) DetachedOpen16(dst, nonce, ciphertext, aad []byte, tag [16]byte) ([]byte, error) {
+	return nil, errNoAVX512VAES
+}
+
+func (a AEAD256x4) DetachedOpen32(dst, nonce, ciphertext, aad []byte, tag [32]byte) ([]byte, error) {
+	return nil, errNoAVX512VAES
+}
+
+func (a AEAD256x4) Open(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
+	return nil, errNoAVX512VAES
+}
+
+// Mac256x4 is only declared, like AEAD256x4.
+type Mac256x4 struct{}
+
+func NewMac256x4(key [32]byte) (Mac256x4, error) {
+	return Mac256x4{}, errNoAVX512VAES
+}
+
+func (m Mac256x4) Sum16(nonce []byte, data []byte) [16]byte {
+	panic(errNoAVX512VAES)
+}
+
+func (m Mac256x4) Sum32(nonce []byte, data []byte) [32]byte {
+	panic(errNoAVX512VAES)
+}
+
+func (m Mac256x4) Verify16(nonce []byte, data []byte, tag [16]byte) error {
+	return errNoAVX512VAES
+}
+
+func (m Mac256x4) Verify32(nonce []byte, data []byte, tag [32]byte) error {
+	return errNoAVX512VAES
+}
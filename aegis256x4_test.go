@@ -0,0 +1,224 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+var aegis256x4TestCases = []struct {
+	name string
+
+	// Inputs (all hex-encoded)
+	key            string
+	nonce          string
+	plaintext      string
+	additionalData string
+
+	// Expected outputs (all hex-encoded).
+	expectedCiphertext string
+	expectedTag16      string
+	expectedTag32      string
+}{
+	{
+		name: "TestVector1",
+
+		key: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f",
+		nonce: "101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f",
+		plaintext:      "",
+		additionalData: "",
+
+		expectedCiphertext: "",
+		expectedTag16:      "3b7fee6cee7bf17888ad11ed2397beb4",
+		expectedTag32: "6093a1a8aab20ec635dc1ca71745b01b" +
+			"5bec4fc444c9ffbebd710d4a34d20eaf",
+	},
+
+	{
+		name: "TestVector2",
+
+		key: "000102030405060708090a0b0c0d0e0f" +
+			"101112131415161718191a1b1c1d1e1f",
+		nonce: "101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f",
+		plaintext: "04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"04050607040506070405060704050607" +
+			"0405060704050607",
+		additionalData: "0102030401020304",
+
+		expectedCiphertext: "bfc2085b7e8017da99b0b6d646ae4d01" +
+			"f4ba8f2e7dfca1d759ae48a135139b9a" +
+			"aac6b4f5db810d426be1fdaff4e14541" +
+			"53a34b11da78ed7e418ee2ee9853042e" +
+			"95536aecbb694cea1b16a478eb0d4d1b" +
+			"f6509b1ce652a45af58e0e46ffccfa2d" +
+			"0426e702391d2ff5813808b81748a490" +
+			"dd656465fed61f09",
+		expectedTag16: "b63b611b13975e2f3dc3cb6c2397bfcd",
+		expectedTag32: "7847eace74409ee56c8f4cf63a9c2841" +
+			"ce7c8bd567d7c0ca514c879a190b978c",
+	},
+}
+
+func newAEAD256x4(t testing.TB, key [32]byte) aegis.AEAD256x4 {
+	aead, err := aegis.NewAEAD256x4(key)
+	if err != nil {
+		t.Skipf("AEGIS-256X4 unavailable: %v", err)
+	}
+	return aead
+}
+
+func TestAegis256x4(t *testing.T) {
+	for _, tc := range aegis256x4TestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			aead := newAEAD256x4(t, ([32]byte)(key))
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal16(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen16(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+
+			{
+
+				ciphertext, tag := aead.DetachedSeal32(nil, nonce, unhex(tc.plaintext), unhex(tc.additionalData))
+
+				gotCiphertext := hex.EncodeToString(ciphertext)
+				if gotCiphertext != tc.expectedCiphertext {
+					t.Errorf("got ciphertext=%q, want ciphertext=%q", gotCiphertext, tc.expectedCiphertext)
+				}
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+
+				rtPlaintext, err := aead.DetachedOpen32(nil, nonce, ciphertext, unhex(tc.additionalData), tag)
+				gotRtPlaintext := hex.EncodeToString(rtPlaintext)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				if gotRtPlaintext != tc.plaintext {
+					t.Errorf("got roundtrip plaintext=%q, want plaintext=%q", gotRtPlaintext, tc.plaintext)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkAegis256x4(b *testing.B, plaintext []byte) {
+	var key [32]byte
+	var nonce [32]byte
+	ciphertext := make([]byte, len(plaintext)+16)
+
+	b.SetBytes(int64(len(plaintext)))
+
+	aead := newAEAD256x4(b, key)
+
+	for b.Loop() {
+		ciphertext = ciphertext[:0]
+		ciphertext = aead.Seal(ciphertext, nonce[:], plaintext, nil)
+	}
+}
+
+func BenchmarkAegis256x4(b *testing.B) {
+	for _, length := range []int{64, 16384, 65536} {
+		b.Run(strconv.Itoa(length), func(b *testing.B) {
+			benchmarkAegis256x4(b, make([]byte, length))
+		})
+	}
+}
+
+func TestAegisMac256x4(t *testing.T) {
+	tcs := []struct {
+		name string
+
+		// Inputs (all hex-encoded)
+		key   string
+		nonce string
+		data  string
+
+		// Expected outputs (all hex-encoded).
+		expectedTag16 string
+		expectedTag32 string
+	}{
+		{
+			name: "TestVector1",
+
+			key: "10010000000000000000000000000000" +
+				"00000000000000000000000000000000",
+			nonce: "10000200000000000000000000000000" +
+				"00000000000000000000000000000000",
+			data: "000102030405060708090a0b0c0d0e0f" +
+				"101112131415161718191a1b1c1d1e1f" +
+				"202122",
+
+			expectedTag16: "67e9609ddde5f64bca9af6a7cda7c693",
+			expectedTag32: "b36a16ef07c36d75a91f437502f24f54" +
+				"5b8dfa88648ed116943c29fead3bf10c",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			key := unhex(tc.key)
+			nonce := unhex(tc.nonce)
+
+			mac, err := aegis.NewMac256x4(([32]byte)(key))
+			if err != nil {
+				t.Skipf("AEGIS-256X4 unavailable: %v", err)
+			}
+
+			{
+
+				tag := mac.Sum16(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag16 {
+					t.Errorf("got tag16=%q, want tag=%q", gotTag, tc.expectedTag16)
+				}
+			}
+
+			{
+				tag := mac.Sum32(nonce, unhex(tc.data))
+
+				gotTag := hex.EncodeToString(tag[:])
+				if gotTag != tc.expectedTag32 {
+					t.Errorf("got tag32=%q, want tag=%q", gotTag, tc.expectedTag32)
+				}
+			}
+		})
+	}
+}
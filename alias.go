@@ -0,0 +1,105 @@
+package aegis
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"unsafe"
+)
+
+// sliceForAppend returns head, which is in extended by n bytes, and tail, which
+// is those last n bytes. It only allocates if in lacks the capacity.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	head = slices.Grow(in, n)[:len(in)+n]
+	tail = head[len(in):]
+	return head, tail
+}
+
+// checkAliasing enforces the aliasing rules of crypto/cipher.AEAD on out, the
+// bytes written by a Seal or Open call: out may start exactly where in starts,
+// but must not otherwise overlap it, and must not overlap aad at all.
+func checkAliasing(out, in, aad []byte) {
+	if inexactOverlap(out, in) {
+		panic("aegis: invalid buffer overlap of output and input")
+	}
+	if anyOverlap(out, aad) {
+		panic("aegis: invalid buffer overlap of output and additional data")
+	}
+}
+
+// checkPrefixedAliasing is checkAliasing for output that starts with n bytes,
+// such as a nonce, that are written before in and aad are read: in may start
+// exactly where the output following them starts, and neither may overlap
+// them.
+func checkPrefixedAliasing(out []byte, n int, in, aad []byte) {
+	checkAliasing(out[n:], in, aad)
+	if anyOverlap(out[:n], in) || anyOverlap(out[:n], aad) {
+		panic("aegis: invalid buffer overlap of output and input")
+	}
+}
+
+// anyOverlap reports whether x and y share memory at any index.
+func anyOverlap(x, y []byte) bool {
+	return len(x) > 0 && len(y) > 0 &&
+		uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
+
+// inexactOverlap reports whether x and y share memory at any non-corresponding
+// index.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+	return anyOverlap(x, y)
+}
+
+// checkBatchAliasing enforces the aliasing rules of a batch, in which out[i]
+// is written by message i and ins[i] are the buffers it reads: no output may
+// overlap another, or any buffer read by another message. Each message checks
+// its own output against its own inputs, with checkAliasing.
+//
+// It sorts the outputs by address rather than comparing every pair, so that
+// large batches stay cheap to check.
+func checkBatchAliasing(outs [][]byte, ins [][][]byte) {
+	type span struct {
+		start, end uintptr
+		msg        int
+	}
+	spanOf := func(b []byte, msg int) span {
+		start := uintptr(unsafe.Pointer(unsafe.SliceData(b)))
+		return span{start, start + uintptr(len(b)), msg}
+	}
+
+	spans := make([]span, 0, len(outs))
+	for i, out := range outs {
+		if len(out) > 0 {
+			spans = append(spans, spanOf(out, i))
+		}
+	}
+	slices.SortFunc(spans, func(x, y span) int { return cmp.Compare(x.start, y.start) })
+	for k := 1; k < len(spans); k++ {
+		if spans[k].start < spans[k-1].end {
+			panic("aegis: invalid buffer overlap of outputs in batch")
+		}
+	}
+
+	for i, bufs := range ins {
+		for _, b := range bufs {
+			if len(b) == 0 {
+				continue
+			}
+			in := spanOf(b, i)
+			// The outputs that overlap in are consecutive, and end with the
+			// last one that starts before in ends. Only one of them can be
+			// message i's own.
+			k := sort.Search(len(spans), func(k int) bool { return spans[k].start >= in.end }) - 1
+			if k < 0 || spans[k].end <= in.start {
+				continue
+			}
+			if spans[k].msg != i || k > 0 && spans[k-1].end > in.start {
+				panic("aegis: invalid buffer overlap of output and input of another message in batch")
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package aegis
+
+import (
+	"errors"
+)
+
+var (
+	// ErrAuthFailed is returned when opening a ciphertext whose tag does not
+	// match its contents, its additional data, its nonce or the key.
+	ErrAuthFailed = errors.New("aegis: message authentication failed")
+
+	// ErrCiphertextTooShort is returned when opening a ciphertext that is too
+	// short to hold a tag.
+	ErrCiphertextTooShort = errors.New("aegis: ciphertext too short")
+
+	// ErrInvalidNonceSize is returned when a nonce is not NonceSize bytes long.
+	ErrInvalidNonceSize = errors.New("aegis: invalid nonce size")
+
+	// ErrMessageTooLong is returned when a message or its additional data is
+	// longer than AEGIS allows.
+	ErrMessageTooLong = errors.New("aegis: message too long")
+
+	// ErrInvalidTagSize is returned when a tag size other than 16 or 32 bytes
+	// is requested.
+	ErrInvalidTagSize = errors.New("aegis: tag size must be 16 or 32")
+
+	// ErrNoncesExhausted is returned by a NonceSequence that has returned
+	// every nonce it can.
+	ErrNoncesExhausted = errors.New("aegis: nonce sequence exhausted")
+
+	// ErrMessageLimit is returned by a LimitedAEAD128x2 that has sealed as
+	// many messages as it allows under its key.
+	ErrMessageLimit = errors.New("aegis: message limit for key reached")
+)
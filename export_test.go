@@ -0,0 +1,40 @@
+package aegis
+
+// Implementations lists every implementation, supported or not.
+var Implementations = implementationNames[:]
+
+// SetImplementation switches to the named implementation until restore is
+// called. It reports false, and changes nothing, if the CPU does not support it.
+func SetImplementation(name string) (restore func(), ok bool) {
+	prev := active
+	next := chooseImplementation(detectImplementation(), "aegisimpl="+name)
+	if next.String() != name {
+		return nil, false
+	}
+	active = next
+	return func() { active = prev }, true
+}
+
+// ChooseImplementation is chooseImplementation, with implementations named.
+func ChooseImplementation(detected, godebug string) string {
+	for i, name := range implementationNames {
+		if name == detected {
+			return chooseImplementation(implementation(i), godebug).String()
+		}
+	}
+	panic("unknown implementation " + detected)
+}
+
+// MaxLen is P_MAX and A_MAX.
+const MaxLen = maxLen
+
+// CheckLengths is checkLengths.
+var CheckLengths = checkLengths
+
+// SetNext moves s so that the next nonce it returns has counter next.
+func (s *NonceSequence) SetNext(next uint64) {
+	s.next = next
+}
+
+// ErrSectorTagSize is returned by OpenSector for tags of the wrong size.
+var ErrSectorTagSize = errSectorTagSize
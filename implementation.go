@@ -0,0 +1,86 @@
+package aegis
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// implementation identifies a family of kernels, in increasing order of the
+// CPU features they require.
+type implementation int
+
+const (
+	implPortable implementation = iota
+	implAESNI
+	implVAES256
+	implVAES512
+)
+
+var implementationNames = [...]string{
+	implPortable: "portable",
+	implAESNI:    "aesni",
+	implVAES256:  "vaes256",
+	implVAES512:  "vaes512",
+}
+
+func (i implementation) String() string {
+	return implementationNames[i]
+}
+
+// The constructors of the variants that need more than the portable
+// implementation return these errors when the implementation they are built
+// on is not in use, either because the CPU lacks it or because GODEBUG
+// selected a lesser one.
+var (
+	// errNoAESNI is returned for AEAD128L, AEAD256 and Mac256, which are built
+	// on 128-bit AES-NI instructions.
+	errNoAESNI = errors.New("aegis: AES-NI implementation not available")
+
+	// errNoVAES is returned for AEAD256x2 and Mac256x2, which are built on
+	// 256-bit VAES instructions.
+	errNoVAES = errors.New("aegis: VAES implementation not available")
+
+	// errNoAVX512VAES is returned for the 4-lane variants, which are built on
+	// 512-bit vectors.
+	errNoAVX512VAES = errors.New("aegis: AVX-512 VAES implementation not available")
+)
+
+// active is the implementation in use. It is the best one the CPU supports,
+// unless overridden through GODEBUG.
+var active = chooseImplementation(detectImplementation(), os.Getenv("GODEBUG"))
+
+// chooseImplementation returns the implementation named by the aegisimpl
+// setting in godebug, if the CPU supports it, and detected otherwise.
+func chooseImplementation(detected implementation, godebug string) implementation {
+	for setting := range strings.SplitSeq(godebug, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok || key != "aegisimpl" {
+			continue
+		}
+		for i, name := range implementationNames {
+			if name == value && implementation(i) <= detected {
+				return implementation(i)
+			}
+		}
+	}
+	return detected
+}
+
+// Implementation reports which implementation is in use: "vaes512" or
+// "vaes256" for the VAES kernels, "aesni" for the kernels built on 128-bit
+// AES-NI instructions, or "portable" for the constant-time pure Go fallback.
+//
+// AEAD128x2 and Mac128x2 use the 256-bit VAES kernels under "vaes512", and are
+// available everywhere. The other variants are only available when the
+// implementation they are built on, or a better one, is in use, and their
+// constructors return an error otherwise: "aesni" for AEAD128L, AEAD256 and
+// Mac256, "vaes256" for AEAD256x2 and Mac256x2, and "vaes512" for the 4-lane
+// variants.
+//
+// Setting GODEBUG=aegisimpl=NAME selects the implementation NAME instead, if
+// the CPU supports it, which lets a single machine check every implementation
+// against the others.
+func Implementation() string {
+	return active.String()
+}
@@ -0,0 +1,7 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+func detectImplementation() implementation {
+	return implPortable
+}
@@ -0,0 +1,17 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import "simd/archsimd"
+
+func detectImplementation() implementation {
+	switch {
+	case archsimd.X86.AVX512VAES() && archsimd.X86.AVX2():
+		return implVAES512
+	case archsimd.X86.VAES() && archsimd.X86.AVX2():
+		return implVAES256
+	case archsimd.X86.AVXAES():
+		return implAESNI
+	}
+	return implPortable
+}
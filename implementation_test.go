@@ -0,0 +1,165 @@
+package aegis_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// forEachImplementation runs f as a subtest under every implementation the CPU
+// supports.
+func forEachImplementation(t *testing.T, f func(t *testing.T)) {
+	for _, name := range aegis.Implementations {
+		t.Run(name, func(t *testing.T) {
+			restore, ok := aegis.SetImplementation(name)
+			if !ok {
+				t.Skipf("implementation %q not supported", name)
+			}
+			defer restore()
+
+			f(t)
+		})
+	}
+}
+
+func TestImplementation(t *testing.T) {
+	if got := aegis.Implementation(); !slices.Contains(aegis.Implementations, got) {
+		t.Errorf("Implementation() = %q, want one of %q", got, aegis.Implementations)
+	}
+
+	forEachImplementation(t, func(t *testing.T) {
+		if got, want := aegis.Implementation(), t.Name()[len("TestImplementation/"):]; got != want {
+			t.Errorf("Implementation() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestChooseImplementation(t *testing.T) {
+	tcs := []struct {
+		detected string
+		godebug  string
+		want     string
+	}{
+		{"vaes512", "", "vaes512"},
+		{"vaes512", "aegisimpl=portable", "portable"},
+		{"vaes512", "aegisimpl=aesni", "aesni"},
+		{"vaes512", "http2debug=1,aegisimpl=vaes256", "vaes256"},
+		{"vaes256", "aegisimpl=vaes512", "vaes256"},
+		{"aesni", "aegisimpl=vaes256", "aesni"},
+		{"portable", "aegisimpl=aesni", "portable"},
+		{"vaes256", "aegisimpl=bogus", "vaes256"},
+		{"vaes256", "aegisimpl", "vaes256"},
+		{"vaes256", "xaegisimpl=portable", "vaes256"},
+	}
+
+	for _, tc := range tcs {
+		if got := aegis.ChooseImplementation(tc.detected, tc.godebug); got != tc.want {
+			t.Errorf("chooseImplementation(%s, %q) = %s, want %s", tc.detected, tc.godebug, got, tc.want)
+		}
+	}
+}
+
+// TestImplementationsAgree checks that every implementation produces the same
+// output as the portable one.
+func TestImplementationsAgree(t *testing.T) {
+	type result struct {
+		sealed       []byte
+		tag32        [32]byte
+		mac16, mac32 []byte
+	}
+
+	run := func(t *testing.T) []result {
+		rng := rand.New(rand.NewPCG(1, 2))
+		var results []result
+		for range 200 {
+			key := randomBytes(rng, 16)
+			nonce := randomBytes(rng, 16)
+			plaintext := randomBytes(rng, rng.IntN(300))
+			aad := randomBytes(rng, rng.IntN(300))
+
+			aead := aegis.NewAEAD128x2(([16]byte)(key))
+			mac := aegis.NewMac128x2(([16]byte)(key))
+
+			var r result
+			r.sealed = aead.Seal(nil, nonce, plaintext, aad)
+			opened, err := aead.Open(nil, nonce, r.sealed, aad)
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Fatalf("Open(Seal(%x)) = %x, %v", plaintext, opened, err)
+			}
+			_, r.tag32 = aead.DetachedSeal32(nil, nonce, plaintext, aad)
+			mac16 := mac.Sum16(nonce, plaintext)
+			mac32 := mac.Sum32(nonce, plaintext)
+			r.mac16, r.mac32 = mac16[:], mac32[:]
+			results = append(results, r)
+		}
+		return results
+	}
+
+	var want []result
+	{
+		restore, _ := aegis.SetImplementation("portable")
+		want = run(t)
+		restore()
+	}
+
+	forEachImplementation(t, func(t *testing.T) {
+		got := run(t)
+		for i := range got {
+			if !bytes.Equal(got[i].sealed, want[i].sealed) || got[i].tag32 != want[i].tag32 ||
+				!bytes.Equal(got[i].mac16, want[i].mac16) || !bytes.Equal(got[i].mac32, want[i].mac32) {
+				t.Fatalf("case %d: got %x, want %x", i, got[i], want[i])
+			}
+		}
+	})
+}
+
+func randomBytes(rng *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(rng.Uint32())
+	}
+	return b
+}
+
+// TestConstructorsNeedImplementation checks that the constructors of the
+// variants built on AES-NI or VAES refuse to run under a lesser
+// implementation, such as one selected through GODEBUG, and that their zero
+// values refuse to run at all.
+func TestConstructorsNeedImplementation(t *testing.T) {
+	restore, ok := aegis.SetImplementation("portable")
+	if !ok {
+		t.Fatal("portable implementation unavailable")
+	}
+	defer restore()
+
+	if _, err := aegis.NewAEAD128L([16]byte{}); err == nil {
+		t.Errorf("NewAEAD128L succeeded under portable")
+	}
+	if _, err := aegis.NewAEAD256([32]byte{}); err == nil {
+		t.Errorf("NewAEAD256 succeeded under portable")
+	}
+	if _, err := aegis.NewMac256([32]byte{}); err == nil {
+		t.Errorf("NewMac256 succeeded under portable")
+	}
+	if _, err := aegis.NewAEAD256x2([32]byte{}); err == nil {
+		t.Errorf("NewAEAD256x2 succeeded under portable")
+	}
+	if _, err := aegis.NewMac256x2([32]byte{}); err == nil {
+		t.Errorf("NewMac256x2 succeeded under portable")
+	}
+
+	nonce16, nonce32 := make([]byte, 16), make([]byte, 32)
+	mustPanic(t, "AEAD128L{}.Seal", func() { aegis.AEAD128L{}.Seal(nil, nonce16, nil, nil) })
+	if _, err := (aegis.AEAD128L{}).Open(nil, nonce16, make([]byte, 16), nil); err == nil {
+		t.Errorf("AEAD128L{}.Open succeeded")
+	}
+	mustPanic(t, "AEAD256{}.Seal", func() { aegis.AEAD256{}.Seal(nil, nonce32, nil, nil) })
+	mustPanic(t, "Mac256{}.Sum16", func() { aegis.Mac256{}.Sum16(nonce32, nil) })
+	mustPanic(t, "AEAD256x2{}.Seal", func() { aegis.AEAD256x2{}.Seal(nil, nonce32, nil, nil) })
+	if err := (aegis.Mac256x2{}).Verify16(nonce32, nil, [16]byte{}); err == nil {
+		t.Errorf("Mac256x2{}.Verify16 succeeded")
+	}
+}
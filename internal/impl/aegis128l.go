@@ -0,0 +1,149 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+type State128L struct {
+	V0, V1, V2, V3, V4, V5, V6, V7 archsimd.Uint8x16
+}
+
+func InitState128L(key archsimd.Uint8x16, nonce archsimd.Uint8x16) State128L {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	var state State128L
+	state.V0 = key.Xor(nonce)
+	state.V1 = C1
+	state.V2 = C0
+	state.V3 = C1
+	state.V4 = key.Xor(nonce)
+	state.V5 = key.Xor(C0)
+	state.V6 = key.Xor(C1)
+	state.V7 = key.Xor(C0)
+
+	for range 10 {
+		state = UpdateState128L(state, nonce, key)
+	}
+
+	return state
+}
+
+func UpdateState128L(state State128L, M0 archsimd.Uint8x16, M1 archsimd.Uint8x16) State128L {
+	V0 := state.V0
+	V1 := state.V1
+	V2 := state.V2
+	V3 := state.V3
+	V4 := state.V4
+	V5 := state.V5
+	V6 := state.V6
+	V7 := state.V7
+
+	Tmp := V7
+	V7 = AES(V6, V7)
+	V6 = AES(V5, V6)
+	V5 = AES(V4, V5)
+	V4 = AES(V3, V4)
+	V3 = AES(V2, V3)
+	V2 = AES(V1, V2)
+	V1 = AES(V0, V1)
+	V0 = AES(Tmp, V0)
+
+	V0 = V0.Xor(M0)
+	V4 = V4.Xor(M1)
+
+	return State128L{V0, V1, V2, V3, V4, V5, V6, V7}
+}
+
+func Enc128L(state State128L, P0 archsimd.Uint8x16, P1 archsimd.Uint8x16) (State128L, archsimd.Uint8x16, archsimd.Uint8x16) {
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	state = UpdateState128L(state, P0, P1)
+	C0 := P0.Xor(Z0)
+	C1 := P1.Xor(Z1)
+
+	return state, C0, C1
+}
+
+func Dec128L(state State128L, C0 archsimd.Uint8x16, C1 archsimd.Uint8x16) (State128L, archsimd.Uint8x16, archsimd.Uint8x16) {
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	P0 := C0.Xor(Z0)
+	P1 := C1.Xor(Z1)
+	state = UpdateState128L(state, P0, P1)
+	return state, P0, P1
+}
+
+func DecPartial128L(state State128L, c [32]byte, clen int) (State128L, [32]byte) {
+	if clen <= 0 || clen >= 32 {
+		panic("cn out of range")
+	}
+
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	C0 := archsimd.LoadUint8x16Slice(c[0:16])
+	C1 := archsimd.LoadUint8x16Slice(c[16:32])
+
+	P0 := C0.Xor(Z0)
+	P1 := C1.Xor(Z1)
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	var plaintext [32]byte
+	P0.StoreSlice(plaintext[0:16])
+	P1.StoreSlice(plaintext[16:32])
+	clear(plaintext[clen:])
+
+	P0 = archsimd.LoadUint8x16Slice(plaintext[0:16])
+	P1 = archsimd.LoadUint8x16Slice(plaintext[16:32])
+	state = UpdateState128L(state, P0, P1)
+	return state, plaintext
+}
+
+func finalize128LCommon(state State128L, adlen, msglen uint64) State128L {
+	t := archsimd.LoadUint64x2(&[2]uint64{8 * adlen, 8 * msglen}).AsUint8x16().Xor(state.V2)
+
+	for range 7 {
+		state = UpdateState128L(state, t, t)
+	}
+
+	return state
+}
+
+func Finalize128L_16(state State128L, adlen, msglen uint64) [16]byte {
+	state = finalize128LCommon(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v03 := v01.Xor(v23)
+	v456 := v45.Xor(state.V6)
+	v06 := v03.Xor(v456)
+
+	var ret [16]byte
+	v06.Store(&ret)
+	return ret
+}
+
+func Finalize128L_32(state State128L, adlen, msglen uint64) [32]byte {
+	state = finalize128LCommon(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v67 := state.V6.Xor(state.V7)
+
+	var ret [32]byte
+	v01.Xor(v23).StoreSlice(ret[0:16])
+	v45.Xor(v67).StoreSlice(ret[16:32])
+
+	return ret
+}
+
+func AES(M0 archsimd.Uint8x16, M1 archsimd.Uint8x16) archsimd.Uint8x16 {
+	return M0.AESEncryptOneRound(M1.AsUint32x4())
+}
@@ -1,3 +1,5 @@
+//go:build goexperiment.simd && amd64
+
 package impl
 
 import (
@@ -51,6 +53,33 @@ func InitState128x2(key archsimd.Uint8x16, nonce archsimd.Uint8x16) State128x2 {
 	return state
 }
 
+// LoadState128x2 loads a state previously saved by StoreState128x2, or built
+// by the generic implementation.
+func LoadState128x2(w *Words128x2) State128x2 {
+	return State128x2{
+		archsimd.LoadUint8x32(&w[0]),
+		archsimd.LoadUint8x32(&w[1]),
+		archsimd.LoadUint8x32(&w[2]),
+		archsimd.LoadUint8x32(&w[3]),
+		archsimd.LoadUint8x32(&w[4]),
+		archsimd.LoadUint8x32(&w[5]),
+		archsimd.LoadUint8x32(&w[6]),
+		archsimd.LoadUint8x32(&w[7]),
+	}
+}
+
+// StoreState128x2 saves state into w.
+func StoreState128x2(w *Words128x2, state State128x2) {
+	state.V0.Store(&w[0])
+	state.V1.Store(&w[1])
+	state.V2.Store(&w[2])
+	state.V3.Store(&w[3])
+	state.V4.Store(&w[4])
+	state.V5.Store(&w[5])
+	state.V6.Store(&w[6])
+	state.V7.Store(&w[7])
+}
+
 func UpdateState128x2(state State128x2, M0 archsimd.Uint8x32, M1 archsimd.Uint8x32) State128x2 {
 	V0 := state.V0
 	V1 := state.V1
@@ -170,8 +199,12 @@ func Finalize128x2_32(state State128x2, adlen, msglen uint64) [32]byte {
 }
 
 func Finalize128x2Mac_16(state State128x2, dlen uint64) [16]byte {
-	state = finalize128x2Common(state, dlen, 16)
+	return finalize128x2MacTail_16(finalize128x2Common(state, dlen, 16))
+}
 
+// finalize128x2MacTail_16 finishes Finalize128x2Mac_16 once the state has
+// absorbed the lengths.
+func finalize128x2MacTail_16(state State128x2) [16]byte {
 	v01 := state.V0.Xor(state.V1)
 	v23 := state.V2.Xor(state.V3)
 	v45 := state.V4.Xor(state.V5)
@@ -204,8 +237,12 @@ func Finalize128x2Mac_16(state State128x2, dlen uint64) [16]byte {
 }
 
 func Finalize128x2Mac_32(state State128x2, dlen uint64) [32]byte {
-	state = finalize128x2Common(state, dlen, 32)
+	return finalize128x2MacTail_32(finalize128x2Common(state, dlen, 32))
+}
 
+// finalize128x2MacTail_32 finishes Finalize128x2Mac_32 once the state has
+// absorbed the lengths.
+func finalize128x2MacTail_32(state State128x2) [32]byte {
 	{
 
 		v01 := state.V0.GetHi().Xor(state.V1.GetHi())
@@ -0,0 +1,207 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+// State128x2AESNI is an AEGIS-128X2 state for CPUs that have AES-NI but not
+// VAES. Each lane is held as an AEGIS-128L state, since the two lanes only
+// meet during initialization and finalization.
+type State128x2AESNI struct {
+	Lane0, Lane1 State128L
+}
+
+func InitState128x2AESNI(key archsimd.Uint8x16, nonce archsimd.Uint8x16) State128x2AESNI {
+	return State128x2AESNI{
+		initLane128x2AESNI(key, nonce, 0),
+		initLane128x2AESNI(key, nonce, 1),
+	}
+}
+
+func initLane128x2AESNI(key archsimd.Uint8x16, nonce archsimd.Uint8x16, lane byte) State128L {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	var state State128L
+	state.V0 = key.Xor(nonce)
+	state.V1 = C1
+	state.V2 = C0
+	state.V3 = C1
+	state.V4 = key.Xor(nonce)
+	state.V5 = key.Xor(C0)
+	state.V6 = key.Xor(C1)
+	state.V7 = key.Xor(C0)
+
+	Ctx := archsimd.LoadUint8x16(&[16]byte{0: lane, 1: 0x01})
+
+	for range 10 {
+		state.V3 = state.V3.Xor(Ctx)
+		state.V7 = state.V7.Xor(Ctx)
+		state = UpdateState128L(state, nonce, key)
+	}
+
+	return state
+}
+
+// LoadState128x2AESNI loads a state previously saved by StoreState128x2AESNI,
+// or built by another implementation.
+func LoadState128x2AESNI(w *Words128x2) State128x2AESNI {
+	var state State128x2AESNI
+	for i, lane := range [2]*State128L{&state.Lane0, &state.Lane1} {
+		lane.V0 = archsimd.LoadUint8x16Slice(w[0][16*i : 16*i+16])
+		lane.V1 = archsimd.LoadUint8x16Slice(w[1][16*i : 16*i+16])
+		lane.V2 = archsimd.LoadUint8x16Slice(w[2][16*i : 16*i+16])
+		lane.V3 = archsimd.LoadUint8x16Slice(w[3][16*i : 16*i+16])
+		lane.V4 = archsimd.LoadUint8x16Slice(w[4][16*i : 16*i+16])
+		lane.V5 = archsimd.LoadUint8x16Slice(w[5][16*i : 16*i+16])
+		lane.V6 = archsimd.LoadUint8x16Slice(w[6][16*i : 16*i+16])
+		lane.V7 = archsimd.LoadUint8x16Slice(w[7][16*i : 16*i+16])
+	}
+	return state
+}
+
+// StoreState128x2AESNI saves state into w.
+func StoreState128x2AESNI(w *Words128x2, state State128x2AESNI) {
+	for i, lane := range [2]*State128L{&state.Lane0, &state.Lane1} {
+		lane.V0.StoreSlice(w[0][16*i : 16*i+16])
+		lane.V1.StoreSlice(w[1][16*i : 16*i+16])
+		lane.V2.StoreSlice(w[2][16*i : 16*i+16])
+		lane.V3.StoreSlice(w[3][16*i : 16*i+16])
+		lane.V4.StoreSlice(w[4][16*i : 16*i+16])
+		lane.V5.StoreSlice(w[5][16*i : 16*i+16])
+		lane.V6.StoreSlice(w[6][16*i : 16*i+16])
+		lane.V7.StoreSlice(w[7][16*i : 16*i+16])
+	}
+}
+
+// UpdateState128x2AESNI absorbs the 32-byte words M0 and M1, given as their
+// halves for lane 0 (M00, M10) and lane 1 (M01, M11).
+func UpdateState128x2AESNI(state State128x2AESNI, M00, M01, M10, M11 archsimd.Uint8x16) State128x2AESNI {
+	state.Lane0 = UpdateState128L(state.Lane0, M00, M10)
+	state.Lane1 = UpdateState128L(state.Lane1, M01, M11)
+	return state
+}
+
+// Enc128x2AESNI encrypts a 64-byte block, given as the 16-byte quarters P00,
+// P01 (the word P0 for lanes 0 and 1) and P10, P11 (the word P1), and returns
+// the ciphertext in the same order.
+func Enc128x2AESNI(state State128x2AESNI, P00, P01, P10, P11 archsimd.Uint8x16) (State128x2AESNI, archsimd.Uint8x16, archsimd.Uint8x16, archsimd.Uint8x16, archsimd.Uint8x16) {
+	var C00, C01, C10, C11 archsimd.Uint8x16
+	state.Lane0, C00, C10 = Enc128L(state.Lane0, P00, P10)
+	state.Lane1, C01, C11 = Enc128L(state.Lane1, P01, P11)
+	return state, C00, C01, C10, C11
+}
+
+// Dec128x2AESNI is the inverse of Enc128x2AESNI.
+func Dec128x2AESNI(state State128x2AESNI, C00, C01, C10, C11 archsimd.Uint8x16) (State128x2AESNI, archsimd.Uint8x16, archsimd.Uint8x16, archsimd.Uint8x16, archsimd.Uint8x16) {
+	var P00, P01, P10, P11 archsimd.Uint8x16
+	state.Lane0, P00, P10 = Dec128L(state.Lane0, C00, C10)
+	state.Lane1, P01, P11 = Dec128L(state.Lane1, C01, C11)
+	return state, P00, P01, P10, P11
+}
+
+func DecPartial128x2AESNI(state State128x2AESNI, c [64]byte, clen int) (State128x2AESNI, [64]byte) {
+	if clen <= 0 || clen >= 64 {
+		panic("cn out of range")
+	}
+
+	var plaintext [64]byte
+	for i, lane := range [2]*State128L{&state.Lane0, &state.Lane1} {
+		Z0 := lane.V6.Xor(lane.V1).Xor(lane.V2.And(lane.V3))
+		Z1 := lane.V2.Xor(lane.V5).Xor(lane.V6.And(lane.V7))
+
+		C0 := archsimd.LoadUint8x16Slice(c[16*i : 16*i+16])
+		C1 := archsimd.LoadUint8x16Slice(c[32+16*i : 32+16*i+16])
+
+		C0.Xor(Z0).StoreSlice(plaintext[16*i : 16*i+16])
+		C1.Xor(Z1).StoreSlice(plaintext[32+16*i : 32+16*i+16])
+	}
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	clear(plaintext[clen:])
+
+	state = UpdateState128x2AESNI(state,
+		archsimd.LoadUint8x16Slice(plaintext[0:16]),
+		archsimd.LoadUint8x16Slice(plaintext[16:32]),
+		archsimd.LoadUint8x16Slice(plaintext[32:48]),
+		archsimd.LoadUint8x16Slice(plaintext[48:64]),
+	)
+	return state, plaintext
+}
+
+// The tag of AEGIS-128X2 is the XOR of the tags of its lanes, and each lane
+// finalizes exactly like AEGIS-128L.
+
+func Finalize128x2AESNI_16(state State128x2AESNI, adlen, msglen uint64) [16]byte {
+	t0 := Finalize128L_16(state.Lane0, adlen, msglen)
+	t1 := Finalize128L_16(state.Lane1, adlen, msglen)
+
+	var ret [16]byte
+	archsimd.LoadUint8x16(&t0).Xor(archsimd.LoadUint8x16(&t1)).Store(&ret)
+	return ret
+}
+
+func Finalize128x2AESNI_32(state State128x2AESNI, adlen, msglen uint64) [32]byte {
+	t0 := Finalize128L_32(state.Lane0, adlen, msglen)
+	t1 := Finalize128L_32(state.Lane1, adlen, msglen)
+
+	var ret [32]byte
+	archsimd.LoadUint8x16Slice(t0[0:16]).Xor(archsimd.LoadUint8x16Slice(t1[0:16])).StoreSlice(ret[0:16])
+	archsimd.LoadUint8x16Slice(t0[16:32]).Xor(archsimd.LoadUint8x16Slice(t1[16:32])).StoreSlice(ret[16:32])
+	return ret
+}
+
+func Finalize128x2MacAESNI_16(state State128x2AESNI, dlen uint64) [16]byte {
+	state.Lane0 = finalize128LCommon(state.Lane0, dlen, 16)
+	state.Lane1 = finalize128LCommon(state.Lane1, dlen, 16)
+
+	v06 := [2]archsimd.Uint8x16{}
+	for i, lane := range [2]*State128L{&state.Lane0, &state.Lane1} {
+		v01 := lane.V0.Xor(lane.V1)
+		v23 := lane.V2.Xor(lane.V3)
+		v45 := lane.V4.Xor(lane.V5)
+		v06[i] = v01.Xor(v23).Xor(v45.Xor(lane.V6))
+	}
+
+	// Only lane 0 contributes to the tag from here on.
+	state.Lane0 = UpdateState128L(state.Lane0, v06[0], v06[1])
+	lane := finalizeMacLane128x2AESNI(state.Lane0, 16)
+
+	v01 := lane.V0.Xor(lane.V1)
+	v23 := lane.V2.Xor(lane.V3)
+	v45 := lane.V4.Xor(lane.V5)
+
+	var ret [16]byte
+	v01.Xor(v23).Xor(v45.Xor(lane.V6)).Store(&ret)
+	return ret
+}
+
+func Finalize128x2MacAESNI_32(state State128x2AESNI, dlen uint64) [32]byte {
+	state.Lane0 = finalize128LCommon(state.Lane0, dlen, 32)
+	state.Lane1 = finalize128LCommon(state.Lane1, dlen, 32)
+
+	lane := state.Lane1
+	v03 := lane.V0.Xor(lane.V1).Xor(lane.V2.Xor(lane.V3))
+	v47 := lane.V4.Xor(lane.V5).Xor(lane.V6.Xor(lane.V7))
+
+	// Only lane 0 contributes to the tag from here on.
+	state.Lane0 = UpdateState128L(state.Lane0, v03, v47)
+	lane = finalizeMacLane128x2AESNI(state.Lane0, 32)
+
+	var ret [32]byte
+	lane.V0.Xor(lane.V1).Xor(lane.V2.Xor(lane.V3)).StoreSlice(ret[0:16])
+	lane.V4.Xor(lane.V5).Xor(lane.V6.Xor(lane.V7)).StoreSlice(ret[16:32])
+	return ret
+}
+
+func finalizeMacLane128x2AESNI(lane State128L, taglen uint64) State128L {
+	t := archsimd.LoadUint64x2(&[2]uint64{2, 8 * taglen}).AsUint8x16().Xor(lane.V2)
+
+	for range 7 {
+		lane = UpdateState128L(lane, t, t)
+	}
+
+	return lane
+}
@@ -0,0 +1,112 @@
+//go:build goexperiment.simd && amd64
+
+package impl_test
+
+import (
+	"math/rand/v2"
+	"simd/archsimd"
+	"testing"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+// TestAegis128x2AESNI checks that the AES-NI implementation tracks the generic
+// one exactly, block by block.
+func TestAegis128x2AESNI(t *testing.T) {
+	if !archsimd.X86.AVXAES() {
+		t.Skip("CPU does not support AES-NI")
+	}
+
+	rng := rand.New(rand.NewChaCha8([32]byte{}))
+	fill := func(b []byte) {
+		for i := range b {
+			b[i] = byte(rng.Uint32())
+		}
+	}
+	load4 := func(b *[64]byte) (archsimd.Uint8x16, archsimd.Uint8x16, archsimd.Uint8x16, archsimd.Uint8x16) {
+		return archsimd.LoadUint8x16Slice(b[0:16]), archsimd.LoadUint8x16Slice(b[16:32]), archsimd.LoadUint8x16Slice(b[32:48]), archsimd.LoadUint8x16Slice(b[48:64])
+	}
+	store4 := func(b *[64]byte, x0, x1, x2, x3 archsimd.Uint8x16) {
+		x0.StoreSlice(b[0:16])
+		x1.StoreSlice(b[16:32])
+		x2.StoreSlice(b[32:48])
+		x3.StoreSlice(b[48:64])
+	}
+
+	for range 100 {
+		var key, nonce [16]byte
+		fill(key[:])
+		fill(nonce[:])
+
+		state := impl.InitState128x2AESNI(archsimd.LoadUint8x16(&key), archsimd.LoadUint8x16(&nonce))
+		words := impl.InitState128x2Generic(&key, &nonce)
+
+		check := func(step string) {
+			t.Helper()
+			var got impl.Words128x2
+			impl.StoreState128x2AESNI(&got, state)
+			if got != words {
+				t.Fatalf("%s: state mismatch:\naesni:   %x\ngeneric: %x", step, got, words)
+			}
+			var roundtrip impl.Words128x2
+			impl.StoreState128x2AESNI(&roundtrip, impl.LoadState128x2AESNI(&got))
+			if roundtrip != got {
+				t.Fatalf("%s: LoadState128x2AESNI does not invert StoreState128x2AESNI", step)
+			}
+		}
+		check("init")
+
+		var m [64]byte
+		fill(m[:])
+		m00, m01, m10, m11 := load4(&m)
+		state = impl.UpdateState128x2AESNI(state, m00, m01, m10, m11)
+		impl.UpdateState128x2Generic(&words, (*[32]byte)(m[0:32]), (*[32]byte)(m[32:64]))
+		check("update")
+
+		var got, want [64]byte
+		fill(m[:])
+		var c00, c01, c10, c11 archsimd.Uint8x16
+		m00, m01, m10, m11 = load4(&m)
+		state, c00, c01, c10, c11 = impl.Enc128x2AESNI(state, m00, m01, m10, m11)
+		store4(&got, c00, c01, c10, c11)
+		impl.Enc128x2Generic(&words, &want, &m)
+		check("enc")
+		if got != want {
+			t.Fatalf("enc: got %x, want %x", got, want)
+		}
+
+		fill(m[:])
+		var p00, p01, p10, p11 archsimd.Uint8x16
+		m00, m01, m10, m11 = load4(&m)
+		state, p00, p01, p10, p11 = impl.Dec128x2AESNI(state, m00, m01, m10, m11)
+		store4(&got, p00, p01, p10, p11)
+		impl.Dec128x2Generic(&words, &want, &m)
+		check("dec")
+		if got != want {
+			t.Fatalf("dec: got %x, want %x", got, want)
+		}
+
+		fill(m[:])
+		clen := 1 + rng.IntN(63)
+		state, got = impl.DecPartial128x2AESNI(state, m, clen)
+		want = impl.DecPartial128x2Generic(&words, m, clen)
+		check("decPartial")
+		if got != want {
+			t.Fatalf("decPartial: got %x, want %x", got, want)
+		}
+
+		adlen, msglen := rng.Uint64N(1<<20), rng.Uint64N(1<<20)
+		if got, want := impl.Finalize128x2AESNI_16(state, adlen, msglen), impl.Finalize128x2Generic_16(words, adlen, msglen); got != want {
+			t.Fatalf("Finalize_16: got %x, want %x", got, want)
+		}
+		if got, want := impl.Finalize128x2AESNI_32(state, adlen, msglen), impl.Finalize128x2Generic_32(words, adlen, msglen); got != want {
+			t.Fatalf("Finalize_32: got %x, want %x", got, want)
+		}
+		if got, want := impl.Finalize128x2MacAESNI_16(state, adlen), impl.Finalize128x2MacGeneric_16(words, adlen); got != want {
+			t.Fatalf("FinalizeMac_16: got %x, want %x", got, want)
+		}
+		if got, want := impl.Finalize128x2MacAESNI_32(state, adlen), impl.Finalize128x2MacGeneric_32(words, adlen); got != want {
+			t.Fatalf("FinalizeMac_32: got %x, want %x", got, want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package impl
+
+import (
+	"encoding/binary"
+)
+
+// Words128x2 is an AEGIS-128X2 state held in memory rather than in vector
+// registers. Words128x2[i] is V[i], with lane 0 in its first 16 bytes and lane 1
+// in its last 16 bytes.
+//
+// It is the state of the generic implementation, and the form in which the
+// other implementations hand their state to callers.
+type Words128x2 [8][32]byte
+
+func InitState128x2Generic(key, nonce *[16]byte) Words128x2 {
+	C0 := [16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62}
+	C1 := [16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd}
+
+	Key := broadcast128x2Generic(*key)
+	Nonce := broadcast128x2Generic(*nonce)
+
+	var state Words128x2
+	state[0] = xor32(Key, Nonce)
+	state[1] = broadcast128x2Generic(C1)
+	state[2] = broadcast128x2Generic(C0)
+	state[3] = broadcast128x2Generic(C1)
+	state[4] = state[0]
+	state[5] = xor32(Key, state[2])
+	state[6] = xor32(Key, state[1])
+	state[7] = state[5]
+
+	ctx := [32]byte{
+		0:  0x00,
+		1:  0x01,
+		16: 0x01,
+		17: 0x01,
+	}
+
+	for range 10 {
+		state[3] = xor32(state[3], ctx)
+		state[7] = xor32(state[7], ctx)
+		UpdateState128x2Generic(&state, &Nonce, &Key)
+	}
+
+	return state
+}
+
+func UpdateState128x2Generic(state *Words128x2, M0, M1 *[32]byte) {
+	// Each AESGeneric4 call covers two registers, so that new V[i] is
+	// AESRound(V[i-1], V[i]).
+	var in, rk, out [4][64]byte
+	for i := range 8 {
+		copy(in[i/2][32*(i%2):], state[(i+7)%8][:])
+		copy(rk[i/2][32*(i%2):], state[i][:])
+	}
+	for i := range 4 {
+		AESGeneric4(&out[i], &in[i], &rk[i])
+	}
+	for i := range 8 {
+		state[i] = [32]byte(out[i/2][32*(i%2):])
+	}
+
+	state[0] = xor32(state[0], *M0)
+	state[4] = xor32(state[4], *M1)
+}
+
+func z128x2Generic(state *Words128x2) ([32]byte, [32]byte) {
+	Z0 := xor32(xor32(state[6], state[1]), and32(state[2], state[3]))
+	Z1 := xor32(xor32(state[2], state[5]), and32(state[6], state[7]))
+	return Z0, Z1
+}
+
+// Enc128x2Generic encrypts the block src into dst, which may alias src.
+func Enc128x2Generic(state *Words128x2, dst, src *[64]byte) {
+	Z0, Z1 := z128x2Generic(state)
+
+	P0 := [32]byte(src[0:32])
+	P1 := [32]byte(src[32:64])
+	UpdateState128x2Generic(state, &P0, &P1)
+
+	C0 := xor32(P0, Z0)
+	C1 := xor32(P1, Z1)
+	copy(dst[0:32], C0[:])
+	copy(dst[32:64], C1[:])
+}
+
+// Dec128x2Generic decrypts the block src into dst, which may alias src.
+func Dec128x2Generic(state *Words128x2, dst, src *[64]byte) {
+	Z0, Z1 := z128x2Generic(state)
+
+	P0 := xor32([32]byte(src[0:32]), Z0)
+	P1 := xor32([32]byte(src[32:64]), Z1)
+	UpdateState128x2Generic(state, &P0, &P1)
+
+	copy(dst[0:32], P0[:])
+	copy(dst[32:64], P1[:])
+}
+
+func DecPartial128x2Generic(state *Words128x2, c [64]byte, clen int) [64]byte {
+	if clen <= 0 || clen >= 64 {
+		panic("cn out of range")
+	}
+
+	Z0, Z1 := z128x2Generic(state)
+
+	var plaintext [64]byte
+	P0 := xor32([32]byte(c[0:32]), Z0)
+	P1 := xor32([32]byte(c[32:64]), Z1)
+	copy(plaintext[0:32], P0[:])
+	copy(plaintext[32:64], P1[:])
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	clear(plaintext[clen:])
+
+	P0 = [32]byte(plaintext[0:32])
+	P1 = [32]byte(plaintext[32:64])
+	UpdateState128x2Generic(state, &P0, &P1)
+	return plaintext
+}
+
+func finalize128x2CommonGeneric(state *Words128x2, adlen, msglen uint64) {
+	var t0 [16]byte
+	binary.LittleEndian.PutUint64(t0[0:8], 8*adlen)
+	binary.LittleEndian.PutUint64(t0[8:16], 8*msglen)
+	t1 := xor32(broadcast128x2Generic(t0), state[2])
+
+	for range 7 {
+		UpdateState128x2Generic(state, &t1, &t1)
+	}
+}
+
+func Finalize128x2Generic_16(state Words128x2, adlen, msglen uint64) [16]byte {
+	finalize128x2CommonGeneric(&state, adlen, msglen)
+
+	v06 := xorWords128x2(&state, 0, 7)
+	return xor16([16]byte(v06[0:16]), [16]byte(v06[16:32]))
+}
+
+func Finalize128x2Generic_32(state Words128x2, adlen, msglen uint64) [32]byte {
+	finalize128x2CommonGeneric(&state, adlen, msglen)
+
+	v03 := xorWords128x2(&state, 0, 4)
+	v47 := xorWords128x2(&state, 4, 8)
+
+	var ret [32]byte
+	t0 := xor16([16]byte(v03[0:16]), [16]byte(v03[16:32]))
+	t1 := xor16([16]byte(v47[0:16]), [16]byte(v47[16:32]))
+	copy(ret[0:16], t0[:])
+	copy(ret[16:32], t1[:])
+	return ret
+}
+
+func Finalize128x2MacGeneric_16(state Words128x2, dlen uint64) [16]byte {
+	finalize128x2CommonGeneric(&state, dlen, 16)
+
+	v06 := xorWords128x2(&state, 0, 7)
+
+	var x0, x1 [32]byte
+	copy(x0[0:16], v06[0:16])
+	copy(x1[0:16], v06[16:32])
+	UpdateState128x2Generic(&state, &x0, &x1)
+
+	tag := finalize128x2MacLane0Generic(&state, 16)
+	return [16]byte(tag[:16])
+}
+
+func Finalize128x2MacGeneric_32(state Words128x2, dlen uint64) [32]byte {
+	finalize128x2CommonGeneric(&state, dlen, 32)
+
+	v03 := xorWords128x2(&state, 0, 4)
+	v47 := xorWords128x2(&state, 4, 8)
+
+	var x0, x1 [32]byte
+	copy(x0[0:16], v03[16:32])
+	copy(x1[0:16], v47[16:32])
+	UpdateState128x2Generic(&state, &x0, &x1)
+
+	return finalize128x2MacLane0Generic(&state, 32)
+}
+
+// finalize128x2MacLane0Generic runs the last finalization rounds of the MAC,
+// once the tags of every lane have been absorbed, and returns the tag of lane
+// 0. Only the first 16 bytes are meaningful when taglen is 16.
+func finalize128x2MacLane0Generic(state *Words128x2, taglen uint64) [32]byte {
+	var u [16]byte
+	binary.LittleEndian.PutUint64(u[0:8], 2)
+	binary.LittleEndian.PutUint64(u[8:16], 8*taglen)
+
+	var t [32]byte
+	copy(t[0:16], u[:])
+	t = xor32(t, state[2])
+	clear(t[16:32])
+
+	for range 7 {
+		UpdateState128x2Generic(state, &t, &t)
+	}
+
+	var ret [32]byte
+	if taglen == 16 {
+		v06 := xorWords128x2(state, 0, 7)
+		copy(ret[0:16], v06[0:16])
+	} else {
+		v03 := xorWords128x2(state, 0, 4)
+		v47 := xorWords128x2(state, 4, 8)
+		copy(ret[0:16], v03[0:16])
+		copy(ret[16:32], v47[0:16])
+	}
+	return ret
+}
+
+// xorWords128x2 returns the XOR of registers V[lo] through V[hi-1].
+func xorWords128x2(state *Words128x2, lo, hi int) [32]byte {
+	var ret [32]byte
+	for i := lo; i < hi; i++ {
+		ret = xor32(ret, state[i])
+	}
+	return ret
+}
+
+func broadcast128x2Generic(x [16]byte) [32]byte {
+	var ret [32]byte
+	copy(ret[0:16], x[:])
+	copy(ret[16:32], x[:])
+	return ret
+}
+
+func xor16(a, b [16]byte) [16]byte {
+	var ret [16]byte
+	for i := range ret {
+		ret[i] = a[i] ^ b[i]
+	}
+	return ret
+}
+
+func xor32(a, b [32]byte) [32]byte {
+	var ret [32]byte
+	for i := range ret {
+		ret[i] = a[i] ^ b[i]
+	}
+	return ret
+}
+
+func and32(a, b [32]byte) [32]byte {
+	var ret [32]byte
+	for i := range ret {
+		ret[i] = a[i] & b[i]
+	}
+	return ret
+}
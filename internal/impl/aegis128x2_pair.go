@@ -0,0 +1,194 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+// A pair of AEGIS-128X2 states, for two independent messages under the same
+// key, fits in the four lanes of an AEGIS-128X4 state: lanes 0 and 1 hold the
+// first message, and lanes 2 and 3 the second. Every lane is updated by the
+// same AES rounds, so both messages advance with a single instruction stream,
+// which amortizes the latency of initialization and finalization over short
+// messages.
+//
+// Blocks of a pair are passed as 128 bytes: the 64-byte block of the first
+// message followed by that of the second.
+
+func InitPair128x2(key, nonceA, nonceB archsimd.Uint8x16) State128x4 {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	ctx := [64]byte{
+		0:  0x00,
+		1:  0x01,
+		16: 0x01,
+		17: 0x01,
+		32: 0x00,
+		33: 0x01,
+		48: 0x01,
+		49: 0x01,
+	}
+	Ctx := archsimd.LoadUint8x64(&ctx)
+
+	Key := broadcast128x4(key)
+	Nonce := pair128x2(nonceA, nonceB)
+
+	var state State128x4
+	state.V0 = Key.Xor(Nonce)
+	state.V1 = broadcast128x4(C1)
+	state.V2 = broadcast128x4(C0)
+	state.V3 = broadcast128x4(C1)
+	state.V4 = state.V0
+	state.V5 = Key.Xor(state.V2)
+	state.V6 = Key.Xor(state.V1)
+	state.V7 = state.V5
+
+	for range 10 {
+		state.V3 = state.V3.Xor(Ctx)
+		state.V7 = state.V7.Xor(Ctx)
+		state = UpdateState128x4(state, Nonce, Key)
+	}
+
+	return state
+}
+
+// SplitPair128x2 returns the states of the two messages of a pair.
+func SplitPair128x2(state State128x4) (State128x2, State128x2) {
+	a := State128x2{
+		state.V0.GetLo(), state.V1.GetLo(), state.V2.GetLo(), state.V3.GetLo(),
+		state.V4.GetLo(), state.V5.GetLo(), state.V6.GetLo(), state.V7.GetLo(),
+	}
+	b := State128x2{
+		state.V0.GetHi(), state.V1.GetHi(), state.V2.GetHi(), state.V3.GetHi(),
+		state.V4.GetHi(), state.V5.GetHi(), state.V6.GetHi(), state.V7.GetHi(),
+	}
+	return a, b
+}
+
+// JoinPair128x2 is the inverse of SplitPair128x2.
+func JoinPair128x2(a, b State128x2) State128x4 {
+	return State128x4{
+		join128x2(a.V0, b.V0), join128x2(a.V1, b.V1), join128x2(a.V2, b.V2), join128x2(a.V3, b.V3),
+		join128x2(a.V4, b.V4), join128x2(a.V5, b.V5), join128x2(a.V6, b.V6), join128x2(a.V7, b.V7),
+	}
+}
+
+func AbsorbPair128x2(state State128x4, m *[128]byte) State128x4 {
+	M0, M1 := loadPair128x2(m)
+	return UpdateState128x4(state, M0, M1)
+}
+
+func EncPair128x2(state State128x4, p *[128]byte) (State128x4, [128]byte) {
+	P0, P1 := loadPair128x2(p)
+
+	var C0, C1 archsimd.Uint8x64
+	state, C0, C1 = Enc128x4(state, P0, P1)
+	return state, storePair128x2(C0, C1)
+}
+
+// DecPair128x2 decrypts a block of each message. clenA and clenB are the
+// lengths of those blocks, between 1 and 64; the bytes of c past them are
+// ignored, and the matching bytes of the plaintext are zero.
+func DecPair128x2(state State128x4, c *[128]byte, clenA, clenB int) (State128x4, [128]byte) {
+	if clenA <= 0 || clenA > 64 || clenB <= 0 || clenB > 64 {
+		panic("cn out of range")
+	}
+
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	C0, C1 := loadPair128x2(c)
+	plaintext := storePair128x2(C0.Xor(Z0), C1.Xor(Z1))
+
+	// Zero-out any plaintext bytes after the ciphertext lengths.
+	clear(plaintext[clenA:64])
+	clear(plaintext[64+clenB:])
+
+	P0, P1 := loadPair128x2(&plaintext)
+	state = UpdateState128x4(state, P0, P1)
+	return state, plaintext
+}
+
+func finalizePair128x2Common(state State128x4, adlenA, msglenA, adlenB, msglenB uint64) State128x4 {
+	tA := archsimd.LoadUint64x2(&[2]uint64{8 * adlenA, 8 * msglenA}).AsUint8x16()
+	tB := archsimd.LoadUint64x2(&[2]uint64{8 * adlenB, 8 * msglenB}).AsUint8x16()
+	t := pair128x2(tA, tB).Xor(state.V2)
+
+	for range 7 {
+		state = UpdateState128x4(state, t, t)
+	}
+
+	return state
+}
+
+func FinalizePair128x2_16(state State128x4, adlenA, msglenA, adlenB, msglenB uint64) ([16]byte, [16]byte) {
+	state = finalizePair128x2Common(state, adlenA, msglenA, adlenB, msglenB)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v06 := v01.Xor(v23).Xor(v45.Xor(state.V6))
+
+	var a, b [16]byte
+	v06.GetLo().GetLo().Xor(v06.GetLo().GetHi()).Store(&a)
+	v06.GetHi().GetLo().Xor(v06.GetHi().GetHi()).Store(&b)
+	return a, b
+}
+
+func FinalizePair128x2_32(state State128x4, adlenA, msglenA, adlenB, msglenB uint64) ([32]byte, [32]byte) {
+	state = finalizePair128x2Common(state, adlenA, msglenA, adlenB, msglenB)
+
+	v03 := state.V0.Xor(state.V1).Xor(state.V2.Xor(state.V3))
+	v47 := state.V4.Xor(state.V5).Xor(state.V6.Xor(state.V7))
+
+	var a, b [32]byte
+	v03.GetLo().GetLo().Xor(v03.GetLo().GetHi()).StoreSlice(a[0:16])
+	v47.GetLo().GetLo().Xor(v47.GetLo().GetHi()).StoreSlice(a[16:32])
+	v03.GetHi().GetLo().Xor(v03.GetHi().GetHi()).StoreSlice(b[0:16])
+	v47.GetHi().GetLo().Xor(v47.GetHi().GetHi()).StoreSlice(b[16:32])
+	return a, b
+}
+
+// The MACs of a pair absorb their lengths together, and then go their own way,
+// since only the first lane of each message takes part in what follows.
+
+func FinalizePair128x2Mac_16(state State128x4, dlenA, dlenB uint64) ([16]byte, [16]byte) {
+	state = finalizePair128x2Common(state, dlenA, 16, dlenB, 16)
+	a, b := SplitPair128x2(state)
+	return finalize128x2MacTail_16(a), finalize128x2MacTail_16(b)
+}
+
+func FinalizePair128x2Mac_32(state State128x4, dlenA, dlenB uint64) ([32]byte, [32]byte) {
+	state = finalizePair128x2Common(state, dlenA, 32, dlenB, 32)
+	a, b := SplitPair128x2(state)
+	return finalize128x2MacTail_32(a), finalize128x2MacTail_32(b)
+}
+
+// loadPair128x2 loads the words M0 and M1 of both messages of a pair.
+func loadPair128x2(m *[128]byte) (archsimd.Uint8x64, archsimd.Uint8x64) {
+	M0 := join128x2(archsimd.LoadUint8x32Slice(m[0:32]), archsimd.LoadUint8x32Slice(m[64:96]))
+	M1 := join128x2(archsimd.LoadUint8x32Slice(m[32:64]), archsimd.LoadUint8x32Slice(m[96:128]))
+	return M0, M1
+}
+
+// storePair128x2 is the inverse of loadPair128x2.
+func storePair128x2(M0, M1 archsimd.Uint8x64) [128]byte {
+	var m [128]byte
+	M0.GetLo().StoreSlice(m[0:32])
+	M1.GetLo().StoreSlice(m[32:64])
+	M0.GetHi().StoreSlice(m[64:96])
+	M1.GetHi().StoreSlice(m[96:128])
+	return m
+}
+
+func join128x2(a, b archsimd.Uint8x32) archsimd.Uint8x64 {
+	return archsimd.Uint8x64{}.SetLo(a).SetHi(b)
+}
+
+// pair128x2 broadcasts a to the lanes of the first message, and b to those of
+// the second.
+func pair128x2(a, b archsimd.Uint8x16) archsimd.Uint8x64 {
+	return join128x2(archsimd.Uint8x32{}.SetLo(a).SetHi(a), archsimd.Uint8x32{}.SetLo(b).SetHi(b))
+}
@@ -0,0 +1,112 @@
+//go:build goexperiment.simd && amd64
+
+package impl_test
+
+import (
+	"math/rand/v2"
+	"simd/archsimd"
+	"testing"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+// TestAegis128x2Pair checks that a pair of states tracks two generic states
+// exactly, block by block.
+func TestAegis128x2Pair(t *testing.T) {
+	if !archsimd.X86.AVX512VAES() {
+		t.Skip("CPU does not support AVX-512 VAES")
+	}
+
+	rng := rand.New(rand.NewChaCha8([32]byte{1}))
+	fill := func(b []byte) {
+		for i := range b {
+			b[i] = byte(rng.Uint32())
+		}
+	}
+
+	for range 100 {
+		var key, nonceA, nonceB [16]byte
+		fill(key[:])
+		fill(nonceA[:])
+		fill(nonceB[:])
+
+		state := impl.InitPair128x2(archsimd.LoadUint8x16(&key), archsimd.LoadUint8x16(&nonceA), archsimd.LoadUint8x16(&nonceB))
+		wordsA := impl.InitState128x2Generic(&key, &nonceA)
+		wordsB := impl.InitState128x2Generic(&key, &nonceB)
+
+		check := func(step string) {
+			t.Helper()
+			a, b := impl.SplitPair128x2(state)
+			var gotA, gotB impl.Words128x2
+			impl.StoreState128x2(&gotA, a)
+			impl.StoreState128x2(&gotB, b)
+			if gotA != wordsA || gotB != wordsB {
+				t.Fatalf("%s: state mismatch:\npair:    %x\n         %x\ngeneric: %x\n         %x", step, gotA, gotB, wordsA, wordsB)
+			}
+			a2, b2 := impl.SplitPair128x2(impl.JoinPair128x2(a, b))
+			var roundA, roundB impl.Words128x2
+			impl.StoreState128x2(&roundA, a2)
+			impl.StoreState128x2(&roundB, b2)
+			if roundA != gotA || roundB != gotB {
+				t.Fatalf("%s: JoinPair128x2 does not invert SplitPair128x2", step)
+			}
+		}
+		check("init")
+
+		var m [128]byte
+		fill(m[:])
+		state = impl.AbsorbPair128x2(state, &m)
+		impl.UpdateState128x2Generic(&wordsA, (*[32]byte)(m[0:32]), (*[32]byte)(m[32:64]))
+		impl.UpdateState128x2Generic(&wordsB, (*[32]byte)(m[64:96]), (*[32]byte)(m[96:128]))
+		check("absorb")
+
+		var got, want [128]byte
+		fill(m[:])
+		state, got = impl.EncPair128x2(state, &m)
+		impl.Enc128x2Generic(&wordsA, (*[64]byte)(want[0:64]), (*[64]byte)(m[0:64]))
+		impl.Enc128x2Generic(&wordsB, (*[64]byte)(want[64:128]), (*[64]byte)(m[64:128]))
+		check("enc")
+		if got != want {
+			t.Fatalf("enc: got %x, want %x", got, want)
+		}
+
+		fill(m[:])
+		state, got = impl.DecPair128x2(state, &m, 64, 64)
+		impl.Dec128x2Generic(&wordsA, (*[64]byte)(want[0:64]), (*[64]byte)(m[0:64]))
+		impl.Dec128x2Generic(&wordsB, (*[64]byte)(want[64:128]), (*[64]byte)(m[64:128]))
+		check("dec")
+		if got != want {
+			t.Fatalf("dec: got %x, want %x", got, want)
+		}
+
+		fill(m[:])
+		clenA := 1 + rng.IntN(63)
+		clenB := 1 + rng.IntN(63)
+		state, got = impl.DecPair128x2(state, &m, clenA, clenB)
+		wantA := impl.DecPartial128x2Generic(&wordsA, [64]byte(m[0:64]), clenA)
+		wantB := impl.DecPartial128x2Generic(&wordsB, [64]byte(m[64:128]), clenB)
+		check("decPartial")
+		if [64]byte(got[0:64]) != wantA || [64]byte(got[64:128]) != wantB {
+			t.Fatalf("decPartial: got %x, want %x%x", got, wantA, wantB)
+		}
+
+		adlenA, msglenA := rng.Uint64N(1<<20), rng.Uint64N(1<<20)
+		adlenB, msglenB := rng.Uint64N(1<<20), rng.Uint64N(1<<20)
+		gotA16, gotB16 := impl.FinalizePair128x2_16(state, adlenA, msglenA, adlenB, msglenB)
+		if gotA16 != impl.Finalize128x2Generic_16(wordsA, adlenA, msglenA) || gotB16 != impl.Finalize128x2Generic_16(wordsB, adlenB, msglenB) {
+			t.Fatalf("Finalize_16 mismatch")
+		}
+		gotA32, gotB32 := impl.FinalizePair128x2_32(state, adlenA, msglenA, adlenB, msglenB)
+		if gotA32 != impl.Finalize128x2Generic_32(wordsA, adlenA, msglenA) || gotB32 != impl.Finalize128x2Generic_32(wordsB, adlenB, msglenB) {
+			t.Fatalf("Finalize_32 mismatch")
+		}
+		gotA16, gotB16 = impl.FinalizePair128x2Mac_16(state, adlenA, adlenB)
+		if gotA16 != impl.Finalize128x2MacGeneric_16(wordsA, adlenA) || gotB16 != impl.Finalize128x2MacGeneric_16(wordsB, adlenB) {
+			t.Fatalf("FinalizeMac_16 mismatch")
+		}
+		gotA32, gotB32 = impl.FinalizePair128x2Mac_32(state, adlenA, adlenB)
+		if gotA32 != impl.Finalize128x2MacGeneric_32(wordsA, adlenA) || gotB32 != impl.Finalize128x2MacGeneric_32(wordsB, adlenB) {
+			t.Fatalf("FinalizeMac_32 mismatch")
+		}
+	}
+}
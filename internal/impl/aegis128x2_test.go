@@ -1,8 +1,11 @@
+//go:build goexperiment.simd && amd64
+
 package impl_test
 
 import (
 	"encoding/hex"
 	"fmt"
+	"math/rand/v2"
 	"simd/archsimd"
 	"strings"
 	"testing"
@@ -65,17 +68,94 @@ V[7,1]: 3407279a54ce76d2e2e8a90ec5d108eb`, "\n")
 
 }
 
-func unhex(h string) []byte {
-	b, err := hex.DecodeString(h)
-	if err != nil {
-		panic(err)
-	}
-
-	return b
-}
-
 func hexSIMD(a archsimd.Uint8x16) string {
 	var b [16]byte
 	a.StoreSlice(b[:])
 	return hex.EncodeToString(b[:])
 }
+
+// TestAegis128x2Generic checks that the generic implementation tracks the VAES
+// one exactly, block by block.
+func TestAegis128x2Generic(t *testing.T) {
+	if !archsimd.X86.VAES() || !archsimd.X86.AVX2() {
+		t.Skip("CPU does not support VAES")
+	}
+
+	rng := rand.New(rand.NewChaCha8([32]byte{}))
+	fill := func(b []byte) {
+		for i := range b {
+			b[i] = byte(rng.Uint32())
+		}
+	}
+
+	for range 100 {
+		var key, nonce [16]byte
+		fill(key[:])
+		fill(nonce[:])
+
+		state := impl.InitState128x2(archsimd.LoadUint8x16(&key), archsimd.LoadUint8x16(&nonce))
+		words := impl.InitState128x2Generic(&key, &nonce)
+
+		check := func(step string) {
+			t.Helper()
+			var got impl.Words128x2
+			impl.StoreState128x2(&got, state)
+			if got != words {
+				t.Fatalf("%s: state mismatch:\nvaes:    %x\ngeneric: %x", step, got, words)
+			}
+		}
+		check("init")
+
+		var m [64]byte
+		fill(m[:])
+		state = impl.UpdateState128x2(state, archsimd.LoadUint8x32Slice(m[0:32]), archsimd.LoadUint8x32Slice(m[32:64]))
+		impl.UpdateState128x2Generic(&words, (*[32]byte)(m[0:32]), (*[32]byte)(m[32:64]))
+		check("update")
+
+		var want, got [64]byte
+		fill(m[:])
+		var c0, c1 archsimd.Uint8x32
+		state, c0, c1 = impl.Enc128x2(state, archsimd.LoadUint8x32Slice(m[0:32]), archsimd.LoadUint8x32Slice(m[32:64]))
+		c0.StoreSlice(want[0:32])
+		c1.StoreSlice(want[32:64])
+		impl.Enc128x2Generic(&words, &got, &m)
+		check("enc")
+		if got != want {
+			t.Fatalf("enc: got %x, want %x", got, want)
+		}
+
+		fill(m[:])
+		var p0, p1 archsimd.Uint8x32
+		state, p0, p1 = impl.Dec128x2(state, archsimd.LoadUint8x32Slice(m[0:32]), archsimd.LoadUint8x32Slice(m[32:64]))
+		p0.StoreSlice(want[0:32])
+		p1.StoreSlice(want[32:64])
+		impl.Dec128x2Generic(&words, &got, &m)
+		check("dec")
+		if got != want {
+			t.Fatalf("dec: got %x, want %x", got, want)
+		}
+
+		fill(m[:])
+		clen := 1 + rng.IntN(63)
+		state, want = impl.DecPartial128x2(state, m, clen)
+		got = impl.DecPartial128x2Generic(&words, m, clen)
+		check("decPartial")
+		if got != want {
+			t.Fatalf("decPartial: got %x, want %x", got, want)
+		}
+
+		adlen, msglen := rng.Uint64N(1<<20), rng.Uint64N(1<<20)
+		if got, want := impl.Finalize128x2Generic_16(words, adlen, msglen), impl.Finalize128x2_16(state, adlen, msglen); got != want {
+			t.Fatalf("Finalize_16: got %x, want %x", got, want)
+		}
+		if got, want := impl.Finalize128x2Generic_32(words, adlen, msglen), impl.Finalize128x2_32(state, adlen, msglen); got != want {
+			t.Fatalf("Finalize_32: got %x, want %x", got, want)
+		}
+		if got, want := impl.Finalize128x2MacGeneric_16(words, adlen), impl.Finalize128x2Mac_16(state, adlen); got != want {
+			t.Fatalf("FinalizeMac_16: got %x, want %x", got, want)
+		}
+		if got, want := impl.Finalize128x2MacGeneric_32(words, adlen), impl.Finalize128x2Mac_32(state, adlen); got != want {
+			t.Fatalf("FinalizeMac_32: got %x, want %x", got, want)
+		}
+	}
+}
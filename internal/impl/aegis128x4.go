@@ -0,0 +1,275 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+type State128x4 struct {
+	V0, V1, V2, V3, V4, V5, V6, V7 archsimd.Uint8x64
+}
+
+func InitState128x4(key archsimd.Uint8x16, nonce archsimd.Uint8x16) State128x4 {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	S0 := key.Xor(nonce)
+	S1 := C1
+	S2 := C0
+	S3 := C1
+	S4 := S0
+	S5 := key.Xor(C0)
+	S6 := key.Xor(C1)
+	S7 := S5
+
+	ctx := [64]byte{
+		0:  0x00,
+		1:  0x03,
+		16: 0x01,
+		17: 0x03,
+		32: 0x02,
+		33: 0x03,
+		48: 0x03,
+		49: 0x03,
+	}
+	Ctx := archsimd.LoadUint8x64(&ctx)
+
+	var state State128x4
+	state.V0 = broadcast128x4(S0)
+	state.V1 = broadcast128x4(S1)
+	state.V2 = broadcast128x4(S2)
+	state.V3 = broadcast128x4(S3)
+	state.V4 = broadcast128x4(S4)
+	state.V5 = broadcast128x4(S5)
+	state.V6 = broadcast128x4(S6)
+	state.V7 = broadcast128x4(S7)
+
+	Key := broadcast128x4(key)
+	Nonce := broadcast128x4(nonce)
+
+	for range 10 {
+		state.V3 = state.V3.Xor(Ctx)
+		state.V7 = state.V7.Xor(Ctx)
+		state = UpdateState128x4(state, Nonce, Key)
+	}
+
+	return state
+}
+
+func UpdateState128x4(state State128x4, M0 archsimd.Uint8x64, M1 archsimd.Uint8x64) State128x4 {
+	V0 := state.V0
+	V1 := state.V1
+	V2 := state.V2
+	V3 := state.V3
+	V4 := state.V4
+	V5 := state.V5
+	V6 := state.V6
+	V7 := state.V7
+
+	Tmp := V7
+	V7 = AESx4(V6, V7)
+	V6 = AESx4(V5, V6)
+	V5 = AESx4(V4, V5)
+	V4 = AESx4(V3, V4)
+	V3 = AESx4(V2, V3)
+	V2 = AESx4(V1, V2)
+	V1 = AESx4(V0, V1)
+	V0 = AESx4(Tmp, V0)
+
+	V0 = V0.Xor(M0)
+	V4 = V4.Xor(M1)
+
+	return State128x4{V0, V1, V2, V3, V4, V5, V6, V7}
+}
+
+func Enc128x4(state State128x4, P0 archsimd.Uint8x64, P1 archsimd.Uint8x64) (State128x4, archsimd.Uint8x64, archsimd.Uint8x64) {
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	state = UpdateState128x4(state, P0, P1)
+	C0 := P0.Xor(Z0)
+	C1 := P1.Xor(Z1)
+
+	return state, C0, C1
+}
+
+func Dec128x4(state State128x4, C0 archsimd.Uint8x64, C1 archsimd.Uint8x64) (State128x4, archsimd.Uint8x64, archsimd.Uint8x64) {
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	P0 := C0.Xor(Z0)
+	P1 := C1.Xor(Z1)
+	state = UpdateState128x4(state, P0, P1)
+	return state, P0, P1
+}
+
+func DecPartial128x4(state State128x4, c [128]byte, clen int) (State128x4, [128]byte) {
+	if clen <= 0 || clen >= 128 {
+		panic("cn out of range")
+	}
+
+	Z0 := state.V6.Xor(state.V1).Xor(state.V2.And(state.V3))
+	Z1 := state.V2.Xor(state.V5).Xor(state.V6.And(state.V7))
+
+	C0 := archsimd.LoadUint8x64Slice(c[0:64])
+	C1 := archsimd.LoadUint8x64Slice(c[64:128])
+
+	P0 := C0.Xor(Z0)
+	P1 := C1.Xor(Z1)
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	var plaintext [128]byte
+	P0.StoreSlice(plaintext[0:64])
+	P1.StoreSlice(plaintext[64:128])
+	clear(plaintext[clen:])
+
+	P0 = archsimd.LoadUint8x64Slice(plaintext[0:64])
+	P1 = archsimd.LoadUint8x64Slice(plaintext[64:128])
+	state = UpdateState128x4(state, P0, P1)
+	return state, plaintext
+}
+
+func finalize128x4Common(state State128x4, adlen, msglen uint64) State128x4 {
+	t0 := archsimd.LoadUint64x2(&[2]uint64{8 * adlen, 8 * msglen}).AsUint8x16()
+	t1 := broadcast128x4(t0).Xor(state.V2)
+
+	for range 7 {
+		state = UpdateState128x4(state, t1, t1)
+	}
+
+	return state
+}
+
+func Finalize128x4_16(state State128x4, adlen, msglen uint64) [16]byte {
+	state = finalize128x4Common(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v03 := v01.Xor(v23)
+	v456 := v45.Xor(state.V6)
+	v06 := v03.Xor(v456)
+
+	var ret [16]byte
+	xorLanes128x4(v06).Store(&ret)
+	return ret
+}
+
+func Finalize128x4_32(state State128x4, adlen, msglen uint64) [32]byte {
+	state = finalize128x4Common(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v67 := state.V6.Xor(state.V7)
+
+	var ret [32]byte
+
+	v03 := v01.Xor(v23)
+	xorLanes128x4(v03).StoreSlice(ret[0:16])
+
+	v47 := v45.Xor(v67)
+	xorLanes128x4(v47).StoreSlice(ret[16:32])
+
+	return ret
+}
+
+func Finalize128x4Mac_16(state State128x4, dlen uint64) [16]byte {
+	state = finalize128x4Common(state, dlen, 16)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v03 := v01.Xor(v23)
+	v456 := v45.Xor(state.V6)
+	v06 := v03.Xor(v456)
+
+	// Absorb the per-lane tags into the first lane, two lanes at a time.
+	for _, v := range [2]archsimd.Uint8x32{v06.GetLo(), v06.GetHi()} {
+		x0 := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(v.GetLo()))
+		x1 := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(v.GetHi()))
+		state = UpdateState128x4(state, x0, x1)
+	}
+
+	u := archsimd.LoadUint64x2(&[2]uint64{4, 8 * 16}).AsUint8x16()
+	t := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lane0x4(state.V2).Xor(u)))
+
+	for range 7 {
+		state = UpdateState128x4(state, t, t)
+	}
+
+	t01 := lane0x4(state.V0).Xor(lane0x4(state.V1))
+	t23 := lane0x4(state.V2).Xor(lane0x4(state.V3))
+	t45 := lane0x4(state.V4).Xor(lane0x4(state.V5))
+	t03 := t01.Xor(t23)
+	t456 := t45.Xor(lane0x4(state.V6))
+	t06 := t03.Xor(t456)
+
+	var ret [16]byte
+	t06.Store(&ret)
+
+	return ret
+}
+
+func Finalize128x4Mac_32(state State128x4, dlen uint64) [32]byte {
+	state = finalize128x4Common(state, dlen, 32)
+
+	{
+		v01 := state.V0.Xor(state.V1)
+		v23 := state.V2.Xor(state.V3)
+		v03 := v01.Xor(v23)
+
+		v45 := state.V4.Xor(state.V5)
+		v67 := state.V6.Xor(state.V7)
+		v47 := v45.Xor(v67)
+
+		// Absorb the tags of every lane but the first into the first lane.
+		lanes03 := [3]archsimd.Uint8x16{v03.GetLo().GetHi(), v03.GetHi().GetLo(), v03.GetHi().GetHi()}
+		lanes47 := [3]archsimd.Uint8x16{v47.GetLo().GetHi(), v47.GetHi().GetLo(), v47.GetHi().GetHi()}
+		for i := range 3 {
+			x0 := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lanes03[i]))
+			x1 := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lanes47[i]))
+			state = UpdateState128x4(state, x0, x1)
+		}
+	}
+
+	u := archsimd.LoadUint64x2(&[2]uint64{4, 8 * 32}).AsUint8x16()
+	t := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lane0x4(state.V2).Xor(u)))
+
+	for range 7 {
+		state = UpdateState128x4(state, t, t)
+	}
+
+	t01 := lane0x4(state.V0).Xor(lane0x4(state.V1))
+	t23 := lane0x4(state.V2).Xor(lane0x4(state.V3))
+	t45 := lane0x4(state.V4).Xor(lane0x4(state.V5))
+	t67 := lane0x4(state.V6).Xor(lane0x4(state.V7))
+
+	t03 := t01.Xor(t23)
+	t47 := t45.Xor(t67)
+
+	var ret [32]byte
+	t03.StoreSlice(ret[0:16])
+	t47.StoreSlice(ret[16:32])
+
+	return ret
+}
+
+func AESx4(M0 archsimd.Uint8x64, M1 archsimd.Uint8x64) archsimd.Uint8x64 {
+	return M0.AESEncryptOneRound(M1.AsUint32x16())
+}
+
+func broadcast128x4(x archsimd.Uint8x16) archsimd.Uint8x64 {
+	y := archsimd.Uint8x32{}.SetLo(x).SetHi(x)
+	return archsimd.Uint8x64{}.SetLo(y).SetHi(y)
+}
+
+func lane0x4(x archsimd.Uint8x64) archsimd.Uint8x16 {
+	return x.GetLo().GetLo()
+}
+
+func xorLanes128x4(x archsimd.Uint8x64) archsimd.Uint8x16 {
+	y := x.GetLo().Xor(x.GetHi())
+	return y.GetLo().Xor(y.GetHi())
+}
@@ -0,0 +1,136 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+type State256 struct {
+	V0, V1, V2, V3, V4, V5 archsimd.Uint8x16
+}
+
+func InitState256(key0, key1 archsimd.Uint8x16, nonce0, nonce1 archsimd.Uint8x16) State256 {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	KN0 := key0.Xor(nonce0)
+	KN1 := key1.Xor(nonce1)
+
+	var state State256
+	state.V0 = KN0
+	state.V1 = KN1
+	state.V2 = C1
+	state.V3 = C0
+	state.V4 = key0.Xor(C0)
+	state.V5 = key1.Xor(C1)
+
+	for range 4 {
+		state = UpdateState256(state, key0)
+		state = UpdateState256(state, key1)
+		state = UpdateState256(state, KN0)
+		state = UpdateState256(state, KN1)
+	}
+
+	return state
+}
+
+func UpdateState256(state State256, M archsimd.Uint8x16) State256 {
+	V0 := state.V0
+	V1 := state.V1
+	V2 := state.V2
+	V3 := state.V3
+	V4 := state.V4
+	V5 := state.V5
+
+	Tmp := V5
+	V5 = AES(V4, V5)
+	V4 = AES(V3, V4)
+	V3 = AES(V2, V3)
+	V2 = AES(V1, V2)
+	V1 = AES(V0, V1)
+	V0 = AES(Tmp, V0)
+
+	V0 = V0.Xor(M)
+
+	return State256{V0, V1, V2, V3, V4, V5}
+}
+
+func Enc256(state State256, P archsimd.Uint8x16) (State256, archsimd.Uint8x16) {
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	state = UpdateState256(state, P)
+	C := P.Xor(Z)
+
+	return state, C
+}
+
+func Dec256(state State256, C archsimd.Uint8x16) (State256, archsimd.Uint8x16) {
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	P := C.Xor(Z)
+	state = UpdateState256(state, P)
+	return state, P
+}
+
+func DecPartial256(state State256, c [16]byte, clen int) (State256, [16]byte) {
+	if clen <= 0 || clen >= 16 {
+		panic("cn out of range")
+	}
+
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	P := archsimd.LoadUint8x16(&c).Xor(Z)
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	var plaintext [16]byte
+	P.Store(&plaintext)
+	clear(plaintext[clen:])
+
+	state = UpdateState256(state, archsimd.LoadUint8x16(&plaintext))
+	return state, plaintext
+}
+
+func finalize256Common(state State256, adlen, msglen uint64) State256 {
+	t := archsimd.LoadUint64x2(&[2]uint64{8 * adlen, 8 * msglen}).AsUint8x16().Xor(state.V3)
+
+	for range 7 {
+		state = UpdateState256(state, t)
+	}
+
+	return state
+}
+
+func Finalize256_16(state State256, adlen, msglen uint64) [16]byte {
+	state = finalize256Common(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v05 := v01.Xor(v23).Xor(v45)
+
+	var ret [16]byte
+	v05.Store(&ret)
+	return ret
+}
+
+func Finalize256_32(state State256, adlen, msglen uint64) [32]byte {
+	state = finalize256Common(state, adlen, msglen)
+
+	v02 := state.V0.Xor(state.V1).Xor(state.V2)
+	v35 := state.V3.Xor(state.V4).Xor(state.V5)
+
+	var ret [32]byte
+	v02.StoreSlice(ret[0:16])
+	v35.StoreSlice(ret[16:32])
+
+	return ret
+}
+
+func Finalize256Mac_16(state State256, dlen uint64) [16]byte {
+	return Finalize256_16(state, dlen, 16)
+}
+
+func Finalize256Mac_32(state State256, dlen uint64) [32]byte {
+	return Finalize256_32(state, dlen, 32)
+}
@@ -0,0 +1,204 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+type State256x2 struct {
+	V0, V1, V2, V3, V4, V5 archsimd.Uint8x32
+}
+
+func InitState256x2(key0, key1 archsimd.Uint8x16, nonce0, nonce1 archsimd.Uint8x16) State256x2 {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	S0 := key0.Xor(nonce0)
+	S1 := key1.Xor(nonce1)
+	S2 := C1
+	S3 := C0
+	S4 := key0.Xor(C0)
+	S5 := key1.Xor(C1)
+
+	ctx := [32]byte{
+		0:  0x00,
+		1:  0x01,
+		16: 0x01,
+		17: 0x01,
+	}
+	Ctx := archsimd.LoadUint8x32(&ctx)
+
+	var state State256x2
+	state.V0 = archsimd.Uint8x32{}.SetLo(S0).SetHi(S0)
+	state.V1 = archsimd.Uint8x32{}.SetLo(S1).SetHi(S1)
+	state.V2 = archsimd.Uint8x32{}.SetLo(S2).SetHi(S2)
+	state.V3 = archsimd.Uint8x32{}.SetLo(S3).SetHi(S3)
+	state.V4 = archsimd.Uint8x32{}.SetLo(S4).SetHi(S4)
+	state.V5 = archsimd.Uint8x32{}.SetLo(S5).SetHi(S5)
+
+	K0 := archsimd.Uint8x32{}.SetLo(key0).SetHi(key0)
+	K1 := archsimd.Uint8x32{}.SetLo(key1).SetHi(key1)
+	KN0 := state.V0
+	KN1 := state.V1
+
+	for range 4 {
+		for _, M := range [4]archsimd.Uint8x32{K0, K1, KN0, KN1} {
+			state.V3 = state.V3.Xor(Ctx)
+			state.V5 = state.V5.Xor(Ctx)
+			state = UpdateState256x2(state, M)
+		}
+	}
+
+	return state
+}
+
+func UpdateState256x2(state State256x2, M archsimd.Uint8x32) State256x2 {
+	V0 := state.V0
+	V1 := state.V1
+	V2 := state.V2
+	V3 := state.V3
+	V4 := state.V4
+	V5 := state.V5
+
+	Tmp := V5
+	V5 = AESx2(V4, V5)
+	V4 = AESx2(V3, V4)
+	V3 = AESx2(V2, V3)
+	V2 = AESx2(V1, V2)
+	V1 = AESx2(V0, V1)
+	V0 = AESx2(Tmp, V0)
+
+	V0 = V0.Xor(M)
+
+	return State256x2{V0, V1, V2, V3, V4, V5}
+}
+
+func Enc256x2(state State256x2, P archsimd.Uint8x32) (State256x2, archsimd.Uint8x32) {
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	state = UpdateState256x2(state, P)
+	C := P.Xor(Z)
+
+	return state, C
+}
+
+func Dec256x2(state State256x2, C archsimd.Uint8x32) (State256x2, archsimd.Uint8x32) {
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	P := C.Xor(Z)
+	state = UpdateState256x2(state, P)
+	return state, P
+}
+
+func DecPartial256x2(state State256x2, c [32]byte, clen int) (State256x2, [32]byte) {
+	if clen <= 0 || clen >= 32 {
+		panic("cn out of range")
+	}
+
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	P := archsimd.LoadUint8x32(&c).Xor(Z)
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	var plaintext [32]byte
+	P.Store(&plaintext)
+	clear(plaintext[clen:])
+
+	state = UpdateState256x2(state, archsimd.LoadUint8x32(&plaintext))
+	return state, plaintext
+}
+
+func finalize256x2Common(state State256x2, adlen, msglen uint64) State256x2 {
+	t0 := archsimd.LoadUint64x2(&[2]uint64{8 * adlen, 8 * msglen}).AsUint8x16()
+	t1 := archsimd.Uint8x32{}.SetLo(t0).SetHi(t0).Xor(state.V3)
+
+	for range 7 {
+		state = UpdateState256x2(state, t1)
+	}
+
+	return state
+}
+
+func Finalize256x2_16(state State256x2, adlen, msglen uint64) [16]byte {
+	state = finalize256x2Common(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v05 := v01.Xor(v23).Xor(v45)
+
+	var ret [16]byte
+	v05.GetLo().Xor(v05.GetHi()).Store(&ret)
+	return ret
+}
+
+func Finalize256x2_32(state State256x2, adlen, msglen uint64) [32]byte {
+	state = finalize256x2Common(state, adlen, msglen)
+
+	v02 := state.V0.Xor(state.V1).Xor(state.V2)
+	v35 := state.V3.Xor(state.V4).Xor(state.V5)
+
+	var ret [32]byte
+	v02.GetLo().Xor(v02.GetHi()).StoreSlice(ret[0:16])
+	v35.GetLo().Xor(v35.GetHi()).StoreSlice(ret[16:32])
+
+	return ret
+}
+
+func Finalize256x2Mac_16(state State256x2, dlen uint64) [16]byte {
+	state = finalize256x2Common(state, dlen, 16)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v05 := v01.Xor(v23).Xor(v45)
+
+	state = UpdateState256x2(state, archsimd.Uint8x32{}.SetLo(v05.GetLo()))
+	state = UpdateState256x2(state, archsimd.Uint8x32{}.SetLo(v05.GetHi()))
+
+	u := archsimd.LoadUint64x2(&[2]uint64{2, 8 * 16}).AsUint8x16()
+	t := archsimd.Uint8x32{}.SetLo(state.V3.GetLo().Xor(u))
+
+	for range 7 {
+		state = UpdateState256x2(state, t)
+	}
+
+	t01 := state.V0.GetLo().Xor(state.V1.GetLo())
+	t23 := state.V2.GetLo().Xor(state.V3.GetLo())
+	t45 := state.V4.GetLo().Xor(state.V5.GetLo())
+	t05 := t01.Xor(t23).Xor(t45)
+
+	var ret [16]byte
+	t05.Store(&ret)
+
+	return ret
+}
+
+func Finalize256x2Mac_32(state State256x2, dlen uint64) [32]byte {
+	state = finalize256x2Common(state, dlen, 32)
+
+	{
+		v02 := state.V0.GetHi().Xor(state.V1.GetHi()).Xor(state.V2.GetHi())
+		v35 := state.V3.GetHi().Xor(state.V4.GetHi()).Xor(state.V5.GetHi())
+
+		state = UpdateState256x2(state, archsimd.Uint8x32{}.SetLo(v02))
+		state = UpdateState256x2(state, archsimd.Uint8x32{}.SetLo(v35))
+	}
+
+	u := archsimd.LoadUint64x2(&[2]uint64{2, 8 * 32}).AsUint8x16()
+	t := archsimd.Uint8x32{}.SetLo(state.V3.GetLo().Xor(u))
+
+	for range 7 {
+		state = UpdateState256x2(state, t)
+	}
+
+	t02 := state.V0.GetLo().Xor(state.V1.GetLo()).Xor(state.V2.GetLo())
+	t35 := state.V3.GetLo().Xor(state.V4.GetLo()).Xor(state.V5.GetLo())
+
+	var ret [32]byte
+	t02.StoreSlice(ret[0:16])
+	t35.StoreSlice(ret[16:32])
+
+	return ret
+}
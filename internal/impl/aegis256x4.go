@@ -0,0 +1,216 @@
+//go:build goexperiment.simd && amd64
+
+package impl
+
+import (
+	"simd/archsimd"
+)
+
+type State256x4 struct {
+	V0, V1, V2, V3, V4, V5 archsimd.Uint8x64
+}
+
+func InitState256x4(key0, key1 archsimd.Uint8x16, nonce0, nonce1 archsimd.Uint8x16) State256x4 {
+	C0 := archsimd.LoadUint8x16(&[16]byte{0x00, 0x01, 0x01, 0x02, 0x03, 0x05, 0x08, 0x0d, 0x15, 0x22, 0x37, 0x59, 0x90, 0xe9, 0x79, 0x62})
+	C1 := archsimd.LoadUint8x16(&[16]byte{0xdb, 0x3d, 0x18, 0x55, 0x6d, 0xc2, 0x2f, 0xf1, 0x20, 0x11, 0x31, 0x42, 0x73, 0xb5, 0x28, 0xdd})
+
+	S0 := key0.Xor(nonce0)
+	S1 := key1.Xor(nonce1)
+	S2 := C1
+	S3 := C0
+	S4 := key0.Xor(C0)
+	S5 := key1.Xor(C1)
+
+	ctx := [64]byte{
+		0:  0x00,
+		1:  0x03,
+		16: 0x01,
+		17: 0x03,
+		32: 0x02,
+		33: 0x03,
+		48: 0x03,
+		49: 0x03,
+	}
+	Ctx := archsimd.LoadUint8x64(&ctx)
+
+	var state State256x4
+	state.V0 = broadcast128x4(S0)
+	state.V1 = broadcast128x4(S1)
+	state.V2 = broadcast128x4(S2)
+	state.V3 = broadcast128x4(S3)
+	state.V4 = broadcast128x4(S4)
+	state.V5 = broadcast128x4(S5)
+
+	K0 := broadcast128x4(key0)
+	K1 := broadcast128x4(key1)
+	KN0 := state.V0
+	KN1 := state.V1
+
+	for range 4 {
+		for _, M := range [4]archsimd.Uint8x64{K0, K1, KN0, KN1} {
+			state.V3 = state.V3.Xor(Ctx)
+			state.V5 = state.V5.Xor(Ctx)
+			state = UpdateState256x4(state, M)
+		}
+	}
+
+	return state
+}
+
+func UpdateState256x4(state State256x4, M archsimd.Uint8x64) State256x4 {
+	V0 := state.V0
+	V1 := state.V1
+	V2 := state.V2
+	V3 := state.V3
+	V4 := state.V4
+	V5 := state.V5
+
+	Tmp := V5
+	V5 = AESx4(V4, V5)
+	V4 = AESx4(V3, V4)
+	V3 = AESx4(V2, V3)
+	V2 = AESx4(V1, V2)
+	V1 = AESx4(V0, V1)
+	V0 = AESx4(Tmp, V0)
+
+	V0 = V0.Xor(M)
+
+	return State256x4{V0, V1, V2, V3, V4, V5}
+}
+
+func Enc256x4(state State256x4, P archsimd.Uint8x64) (State256x4, archsimd.Uint8x64) {
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	state = UpdateState256x4(state, P)
+	C := P.Xor(Z)
+
+	return state, C
+}
+
+func Dec256x4(state State256x4, C archsimd.Uint8x64) (State256x4, archsimd.Uint8x64) {
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	P := C.Xor(Z)
+	state = UpdateState256x4(state, P)
+	return state, P
+}
+
+func DecPartial256x4(state State256x4, c [64]byte, clen int) (State256x4, [64]byte) {
+	if clen <= 0 || clen >= 64 {
+		panic("cn out of range")
+	}
+
+	Z := state.V1.Xor(state.V4).Xor(state.V5).Xor(state.V2.And(state.V3))
+
+	P := archsimd.LoadUint8x64(&c).Xor(Z)
+
+	// Zero-out any plaintext bytes after the ciphertext length.
+	var plaintext [64]byte
+	P.Store(&plaintext)
+	clear(plaintext[clen:])
+
+	state = UpdateState256x4(state, archsimd.LoadUint8x64(&plaintext))
+	return state, plaintext
+}
+
+func finalize256x4Common(state State256x4, adlen, msglen uint64) State256x4 {
+	t0 := archsimd.LoadUint64x2(&[2]uint64{8 * adlen, 8 * msglen}).AsUint8x16()
+	t1 := broadcast128x4(t0).Xor(state.V3)
+
+	for range 7 {
+		state = UpdateState256x4(state, t1)
+	}
+
+	return state
+}
+
+func Finalize256x4_16(state State256x4, adlen, msglen uint64) [16]byte {
+	state = finalize256x4Common(state, adlen, msglen)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v05 := v01.Xor(v23).Xor(v45)
+
+	var ret [16]byte
+	xorLanes128x4(v05).Store(&ret)
+	return ret
+}
+
+func Finalize256x4_32(state State256x4, adlen, msglen uint64) [32]byte {
+	state = finalize256x4Common(state, adlen, msglen)
+
+	v02 := state.V0.Xor(state.V1).Xor(state.V2)
+	v35 := state.V3.Xor(state.V4).Xor(state.V5)
+
+	var ret [32]byte
+	xorLanes128x4(v02).StoreSlice(ret[0:16])
+	xorLanes128x4(v35).StoreSlice(ret[16:32])
+
+	return ret
+}
+
+func Finalize256x4Mac_16(state State256x4, dlen uint64) [16]byte {
+	state = finalize256x4Common(state, dlen, 16)
+
+	v01 := state.V0.Xor(state.V1)
+	v23 := state.V2.Xor(state.V3)
+	v45 := state.V4.Xor(state.V5)
+	v05 := v01.Xor(v23).Xor(v45)
+
+	// Absorb the per-lane tags into the first lane, one lane at a time.
+	lanes := [4]archsimd.Uint8x16{v05.GetLo().GetLo(), v05.GetLo().GetHi(), v05.GetHi().GetLo(), v05.GetHi().GetHi()}
+	for _, lane := range lanes {
+		state = UpdateState256x4(state, archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lane)))
+	}
+
+	u := archsimd.LoadUint64x2(&[2]uint64{4, 8 * 16}).AsUint8x16()
+	t := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lane0x4(state.V3).Xor(u)))
+
+	for range 7 {
+		state = UpdateState256x4(state, t)
+	}
+
+	t01 := lane0x4(state.V0).Xor(lane0x4(state.V1))
+	t23 := lane0x4(state.V2).Xor(lane0x4(state.V3))
+	t45 := lane0x4(state.V4).Xor(lane0x4(state.V5))
+	t05 := t01.Xor(t23).Xor(t45)
+
+	var ret [16]byte
+	t05.Store(&ret)
+
+	return ret
+}
+
+func Finalize256x4Mac_32(state State256x4, dlen uint64) [32]byte {
+	state = finalize256x4Common(state, dlen, 32)
+
+	{
+		v02 := state.V0.Xor(state.V1).Xor(state.V2)
+		v35 := state.V3.Xor(state.V4).Xor(state.V5)
+
+		// Absorb the tags of every lane but the first into the first lane.
+		lanes02 := [3]archsimd.Uint8x16{v02.GetLo().GetHi(), v02.GetHi().GetLo(), v02.GetHi().GetHi()}
+		lanes35 := [3]archsimd.Uint8x16{v35.GetLo().GetHi(), v35.GetHi().GetLo(), v35.GetHi().GetHi()}
+		for i := range 3 {
+			state = UpdateState256x4(state, archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lanes02[i])))
+			state = UpdateState256x4(state, archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lanes35[i])))
+		}
+	}
+
+	u := archsimd.LoadUint64x2(&[2]uint64{4, 8 * 32}).AsUint8x16()
+	t := archsimd.Uint8x64{}.SetLo(archsimd.Uint8x32{}.SetLo(lane0x4(state.V3).Xor(u)))
+
+	for range 7 {
+		state = UpdateState256x4(state, t)
+	}
+
+	t02 := lane0x4(state.V0).Xor(lane0x4(state.V1)).Xor(lane0x4(state.V2))
+	t35 := lane0x4(state.V3).Xor(lane0x4(state.V4)).Xor(lane0x4(state.V5))
+
+	var ret [32]byte
+	t02.StoreSlice(ret[0:16])
+	t35.StoreSlice(ret[16:32])
+
+	return ret
+}
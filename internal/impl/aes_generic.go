@@ -0,0 +1,219 @@
+package impl
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// AESGeneric4 computes AESRound(src, rk) = MixColumns(ShiftRows(SubBytes(src))) ^ rk
+// on four independent 16-byte blocks, writing the result to dst.
+//
+// It is the portable counterpart of AESEncryptOneRound. SubBytes is computed
+// with a bitsliced circuit and the remaining steps with plain arithmetic, so
+// that neither memory accesses nor branches depend on the data.
+func AESGeneric4(dst, src, rk *[64]byte) {
+	var q [8]uint64
+	for i := range 8 {
+		q[i] = binary.LittleEndian.Uint64(src[8*i:])
+	}
+
+	q = transposeBytes(transposeBits(q))
+	sbox(&q)
+	q = transposeBits(transposeBytes(q))
+
+	var s [64]byte
+	for i := range 8 {
+		binary.LittleEndian.PutUint64(s[8*i:], q[i])
+	}
+
+	for b := 0; b < 64; b += 16 {
+		for c := range 4 {
+			// ShiftRows moves row r of column c+r into column c.
+			w := uint32(s[b+4*c]) |
+				uint32(s[b+4*((c+1)%4)+1])<<8 |
+				uint32(s[b+4*((c+2)%4)+2])<<16 |
+				uint32(s[b+4*((c+3)%4)+3])<<24
+
+			// MixColumns, with all four bytes of the column multiplied at once.
+			w2 := (w&0x7f7f7f7f)<<1 ^ (w>>7&0x01010101)*0x1b
+			w = w2 ^ bits.RotateLeft32(w^w2, -8) ^ bits.RotateLeft32(w, -16) ^ bits.RotateLeft32(w, -24)
+
+			binary.LittleEndian.PutUint32(dst[b+4*c:], w^binary.LittleEndian.Uint32(rk[b+4*c:]))
+		}
+	}
+}
+
+// transposeBits transposes the 8x8 bit matrix held in each word, so that bit
+// k of byte j moves to bit j of byte k. Together with transposeBytes, it
+// converts 64 bytes packed little-endian into q to and from bit planes, where
+// bit k of plane j is bit j of byte k.
+func transposeBits(q [8]uint64) [8]uint64 {
+	for i, x := range q {
+		t := (x ^ x>>7) & 0x00aa00aa00aa00aa
+		x ^= t ^ t<<7
+		t = (x ^ x>>14) & 0x0000cccc0000cccc
+		x ^= t ^ t<<14
+		t = (x ^ x>>28) & 0x00000000f0f0f0f0
+		x ^= t ^ t<<28
+		q[i] = x
+	}
+	return q
+}
+
+// transposeBytes transposes the 8x8 byte matrix formed by the words of q, so
+// that byte j of word g moves to byte g of word j.
+func transposeBytes(q [8]uint64) [8]uint64 {
+	var p [8]uint64
+	for j := range 8 {
+		for g := range 8 {
+			p[j] |= (q[g] >> (8 * j) & 0xff) << (8 * g)
+		}
+	}
+	return p
+}
+
+// sbox applies the AES S-box to bitsliced bytes, where q[i] holds bit i. It
+// is the circuit of Boyar and Peralta, "A new combinational logic
+// minimization technique with applications to cryptology".
+func sbox(q *[8]uint64) {
+	x0 := q[7]
+	x1 := q[6]
+	x2 := q[5]
+	x3 := q[4]
+	x4 := q[3]
+	x5 := q[2]
+	x6 := q[1]
+	x7 := q[0]
+
+	// Top linear transformation.
+	y14 := x3 ^ x5
+	y13 := x0 ^ x6
+	y9 := x0 ^ x3
+	y8 := x0 ^ x5
+	t0 := x1 ^ x2
+	y1 := t0 ^ x7
+	y4 := y1 ^ x3
+	y12 := y13 ^ y14
+	y2 := y1 ^ x0
+	y5 := y1 ^ x6
+	y3 := y5 ^ y8
+	t1 := x4 ^ y12
+	y15 := t1 ^ x5
+	y20 := t1 ^ x1
+	y6 := y15 ^ x7
+	y10 := y15 ^ t0
+	y11 := y20 ^ y9
+	y7 := x7 ^ y11
+	y17 := y10 ^ y11
+	y19 := y10 ^ y8
+	y16 := t0 ^ y11
+	y21 := y13 ^ y16
+	y18 := x0 ^ y16
+
+	// Non-linear section.
+	t2 := y12 & y15
+	t3 := y3 & y6
+	t4 := t3 ^ t2
+	t5 := y4 & x7
+	t6 := t5 ^ t2
+	t7 := y13 & y16
+	t8 := y5 & y1
+	t9 := t8 ^ t7
+	t10 := y2 & y7
+	t11 := t10 ^ t7
+	t12 := y9 & y11
+	t13 := y14 & y17
+	t14 := t13 ^ t12
+	t15 := y8 & y10
+	t16 := t15 ^ t12
+	t17 := t4 ^ t14
+	t18 := t6 ^ t16
+	t19 := t9 ^ t14
+	t20 := t11 ^ t16
+	t21 := t17 ^ y20
+	t22 := t18 ^ y19
+	t23 := t19 ^ y21
+	t24 := t20 ^ y18
+
+	t25 := t21 ^ t22
+	t26 := t21 & t23
+	t27 := t24 ^ t26
+	t28 := t25 & t27
+	t29 := t28 ^ t22
+	t30 := t23 ^ t24
+	t31 := t22 ^ t26
+	t32 := t31 & t30
+	t33 := t32 ^ t24
+	t34 := t23 ^ t33
+	t35 := t27 ^ t33
+	t36 := t24 & t35
+	t37 := t36 ^ t34
+	t38 := t27 ^ t36
+	t39 := t29 & t38
+	t40 := t25 ^ t39
+
+	t41 := t40 ^ t37
+	t42 := t29 ^ t33
+	t43 := t29 ^ t40
+	t44 := t33 ^ t37
+	t45 := t42 ^ t41
+	z0 := t44 & y15
+	z1 := t37 & y6
+	z2 := t33 & x7
+	z3 := t43 & y16
+	z4 := t40 & y1
+	z5 := t29 & y7
+	z6 := t42 & y11
+	z7 := t45 & y17
+	z8 := t41 & y10
+	z9 := t44 & y12
+	z10 := t37 & y3
+	z11 := t33 & y4
+	z12 := t43 & y13
+	z13 := t40 & y5
+	z14 := t29 & y2
+	z15 := t42 & y9
+	z16 := t45 & y14
+	z17 := t41 & y8
+
+	// Bottom linear transformation.
+	t46 := z15 ^ z16
+	t47 := z10 ^ z11
+	t48 := z5 ^ z13
+	t49 := z9 ^ z10
+	t50 := z2 ^ z12
+	t51 := z2 ^ z5
+	t52 := z7 ^ z8
+	t53 := z0 ^ z3
+	t54 := z6 ^ z7
+	t55 := z16 ^ z17
+	t56 := z12 ^ t48
+	t57 := t50 ^ t53
+	t58 := z4 ^ t46
+	t59 := z3 ^ t54
+	t60 := t46 ^ t57
+	t61 := z14 ^ t57
+	t62 := t52 ^ t58
+	t63 := t49 ^ t58
+	t64 := z4 ^ t59
+	t65 := t61 ^ t62
+	t66 := z1 ^ t63
+	s0 := t59 ^ t63
+	s6 := t56 ^ ^t62
+	s7 := t48 ^ ^t60
+	t67 := t64 ^ t65
+	s3 := t53 ^ t66
+	s4 := t51 ^ t66
+	s5 := t47 ^ t65
+	s1 := t64 ^ ^s3
+	s2 := t55 ^ ^t67
+
+	q[7] = s0
+	q[6] = s1
+	q[5] = s2
+	q[4] = s3
+	q[3] = s4
+	q[2] = s5
+	q[1] = s6
+	q[0] = s7
+}
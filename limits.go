@@ -0,0 +1,25 @@
+package aegis
+
+// maxLen is the longest plaintext (P_MAX) and the longest additional data
+// (A_MAX) that AEGIS allows, in bytes, for every variant. Finalization encodes
+// both lengths in bits as 64-bit integers, so anything longer would wrap.
+const maxLen = 1<<61 - 1
+
+// checkLengths returns ErrMessageTooLong if msglen or adlen exceed the limits
+// of AEGIS. The lengths are counters rather than slices so that streaming code,
+// which sees its input in pieces, can share it.
+func checkLengths(msglen, adlen uint64) error {
+	if msglen > maxLen || adlen > maxLen {
+		return ErrMessageTooLong
+	}
+	return nil
+}
+
+// checkArgs returns ErrInvalidNonceSize if nonce is not nonceSize bytes long,
+// and otherwise checks msglen and adlen as checkLengths does.
+func checkArgs(nonce []byte, nonceSize int, msglen, adlen uint64) error {
+	if len(nonce) != nonceSize {
+		return ErrInvalidNonceSize
+	}
+	return checkLengths(msglen, adlen)
+}
@@ -0,0 +1,42 @@
+package aegis_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// TestCheckLengths exercises the length limits with synthetic counters, since
+// no real buffer comes close to them.
+func TestCheckLengths(t *testing.T) {
+	if aegis.MaxLen != 1<<61-1 {
+		t.Fatalf("MaxLen = %d, want 2^61-1", uint64(aegis.MaxLen))
+	}
+
+	tcs := []struct {
+		msglen, adlen uint64
+		want          error
+	}{
+		{0, 0, nil},
+		{aegis.MaxLen, 0, nil},
+		{0, aegis.MaxLen, nil},
+		{aegis.MaxLen, aegis.MaxLen, nil},
+		{aegis.MaxLen + 1, 0, aegis.ErrMessageTooLong},
+		{0, aegis.MaxLen + 1, aegis.ErrMessageTooLong},
+		{aegis.MaxLen + 1, aegis.MaxLen + 1, aegis.ErrMessageTooLong},
+
+		// 8 times these lengths wraps to a small number of bits.
+		{1 << 61, 0, aegis.ErrMessageTooLong},
+		{0, 1<<61 + 1, aegis.ErrMessageTooLong},
+		{math.MaxUint64, 0, aegis.ErrMessageTooLong},
+		{0, math.MaxUint64, aegis.ErrMessageTooLong},
+	}
+
+	for _, tc := range tcs {
+		if got := aegis.CheckLengths(tc.msglen, tc.adlen); !errors.Is(got, tc.want) || (got == nil) != (tc.want == nil) {
+			t.Errorf("checkLengths(%d, %d) = %v, want %v", tc.msglen, tc.adlen, got, tc.want)
+		}
+	}
+}
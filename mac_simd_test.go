@@ -0,0 +1,36 @@
+//go:build goexperiment.simd && amd64
+
+package aegis_test
+
+import (
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+func TestMacConformanceSIMD(t *testing.T) {
+	t.Run("Mac128x4", func(t *testing.T) {
+		testMac(t, func(key []byte) mac {
+			m, err := aegis.NewMac128x4([16]byte(key))
+			if err != nil {
+				t.Skipf("AEGIS-128X4 unavailable: %v", err)
+			}
+			return m
+		}, 16)
+	})
+	t.Run("Mac256", func(t *testing.T) {
+		testMac(t, func(key []byte) mac { return newMac256(t, [32]byte(key)) }, 32)
+	})
+	t.Run("Mac256x2", func(t *testing.T) {
+		testMac(t, func(key []byte) mac { return newMac256x2(t, [32]byte(key)) }, 32)
+	})
+	t.Run("Mac256x4", func(t *testing.T) {
+		testMac(t, func(key []byte) mac {
+			m, err := aegis.NewMac256x4([32]byte(key))
+			if err != nil {
+				t.Skipf("AEGIS-256X4 unavailable: %v", err)
+			}
+			return m
+		}, 32)
+	})
+}
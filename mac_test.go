@@ -0,0 +1,68 @@
+package aegis_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/balasanjay/aegis"
+)
+
+// mac is implemented by every MAC in this package.
+type mac interface {
+	Sum16(nonce []byte, data []byte) [16]byte
+	Sum32(nonce []byte, data []byte) [32]byte
+	Verify16(nonce []byte, data []byte, tag [16]byte) error
+	Verify32(nonce []byte, data []byte, tag [32]byte) error
+}
+
+func TestMacConformance(t *testing.T) {
+	t.Run("Mac128x2", func(t *testing.T) {
+		forEachImplementation(t, func(t *testing.T) {
+			testMac(t, func(key []byte) mac { return aegis.NewMac128x2([16]byte(key)) }, 16)
+		})
+	})
+}
+
+func testMac(t *testing.T, newMac func(key []byte) mac, keySize int) {
+	rng := rand.New(rand.NewPCG(17, 18))
+	m := newMac(randomBytes(rng, keySize))
+	nonce := randomBytes(rng, keySize)
+	data := randomBytes(rng, 100)
+
+	tag16 := m.Sum16(nonce, data)
+	tag32 := m.Sum32(nonce, data)
+	if err := m.Verify16(nonce, data, tag16); err != nil {
+		t.Errorf("Verify16(correct tag) = %v", err)
+	}
+	if err := m.Verify32(nonce, data, tag32); err != nil {
+		t.Errorf("Verify32(correct tag) = %v", err)
+	}
+
+	for i := range 16 {
+		if err := m.Verify16(nonce, data, [16]byte(bytesWithFlippedBit(tag16[:], i))); !errors.Is(err, aegis.ErrAuthFailed) {
+			t.Errorf("Verify16(tag with byte %d flipped) = %v, want %v", i, err, aegis.ErrAuthFailed)
+		}
+	}
+	for i := range 32 {
+		if err := m.Verify32(nonce, data, [32]byte(bytesWithFlippedBit(tag32[:], i))); !errors.Is(err, aegis.ErrAuthFailed) {
+			t.Errorf("Verify32(tag with byte %d flipped) = %v, want %v", i, err, aegis.ErrAuthFailed)
+		}
+	}
+	if err := m.Verify16(nonce, bytesWithFlippedBit(data, 50), tag16); !errors.Is(err, aegis.ErrAuthFailed) {
+		t.Errorf("Verify16(modified data) = %v, want %v", err, aegis.ErrAuthFailed)
+	}
+
+	for _, n := range []int{0, keySize - 1, keySize + 1} {
+		badNonce := make([]byte, n)
+		mustPanic(t, fmt.Sprintf("Sum16 with %d-byte nonce", n), func() { m.Sum16(badNonce, data) })
+		mustPanic(t, fmt.Sprintf("Sum32 with %d-byte nonce", n), func() { m.Sum32(badNonce, data) })
+		if err := m.Verify16(badNonce, data, tag16); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+			t.Errorf("Verify16 with %d-byte nonce = %v, want %v", n, err, aegis.ErrInvalidNonceSize)
+		}
+		if err := m.Verify32(badNonce, data, tag32); !errors.Is(err, aegis.ErrInvalidNonceSize) {
+			t.Errorf("Verify32 with %d-byte nonce = %v, want %v", n, err, aegis.ErrInvalidNonceSize)
+		}
+	}
+}
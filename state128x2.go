@@ -0,0 +1,62 @@
+package aegis
+
+import (
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+// state128x2 is an AEGIS-128X2 state. It is kept in memory between calls, so
+// that the AEAD and MAC code above it is shared by every implementation. The
+// methods that advance it pick an implementation based on the CPU; the
+// *Generic ones below are the portable fallback.
+type state128x2 struct {
+	w impl.Words128x2
+}
+
+func (s *state128x2) initGeneric(key, nonce *[16]byte) {
+	s.w = impl.InitState128x2Generic(key, nonce)
+}
+
+// absorbGeneric absorbs src, whose length must be a multiple of 64.
+func (s *state128x2) absorbGeneric(src []byte) {
+	for i := 0; i+64 <= len(src); i += 64 {
+		m0 := [32]byte(src[i : i+32])
+		m1 := [32]byte(src[i+32 : i+64])
+		impl.UpdateState128x2Generic(&s.w, &m0, &m1)
+	}
+}
+
+// encGeneric encrypts src into dst. Both must have the same length, which must
+// be a multiple of 64.
+func (s *state128x2) encGeneric(dst, src []byte) {
+	for i := 0; i+64 <= len(src); i += 64 {
+		impl.Enc128x2Generic(&s.w, (*[64]byte)(dst[i:i+64]), (*[64]byte)(src[i:i+64]))
+	}
+}
+
+// decGeneric decrypts src into dst. Both must have the same length, which must
+// be a multiple of 64.
+func (s *state128x2) decGeneric(dst, src []byte) {
+	for i := 0; i+64 <= len(src); i += 64 {
+		impl.Dec128x2Generic(&s.w, (*[64]byte)(dst[i:i+64]), (*[64]byte)(src[i:i+64]))
+	}
+}
+
+func (s *state128x2) decPartialGeneric(c [64]byte, clen int) [64]byte {
+	return impl.DecPartial128x2Generic(&s.w, c, clen)
+}
+
+func (s *state128x2) finalize16Generic(adlen, msglen uint64) [16]byte {
+	return impl.Finalize128x2Generic_16(s.w, adlen, msglen)
+}
+
+func (s *state128x2) finalize32Generic(adlen, msglen uint64) [32]byte {
+	return impl.Finalize128x2Generic_32(s.w, adlen, msglen)
+}
+
+func (s *state128x2) finalizeMac16Generic(dlen uint64) [16]byte {
+	return impl.Finalize128x2MacGeneric_16(s.w, dlen)
+}
+
+func (s *state128x2) finalizeMac32Generic(dlen uint64) [32]byte {
+	return impl.Finalize128x2MacGeneric_32(s.w, dlen)
+}
@@ -0,0 +1,39 @@
+//go:build !(goexperiment.simd && amd64)
+
+package aegis
+
+func (s *state128x2) init(key, nonce *[16]byte) {
+	s.initGeneric(key, nonce)
+}
+
+func (s *state128x2) absorb(src []byte) {
+	s.absorbGeneric(src)
+}
+
+func (s *state128x2) enc(dst, src []byte) {
+	s.encGeneric(dst, src)
+}
+
+func (s *state128x2) dec(dst, src []byte) {
+	s.decGeneric(dst, src)
+}
+
+func (s *state128x2) decPartial(c [64]byte, clen int) [64]byte {
+	return s.decPartialGeneric(c, clen)
+}
+
+func (s *state128x2) finalize16(adlen, msglen uint64) [16]byte {
+	return s.finalize16Generic(adlen, msglen)
+}
+
+func (s *state128x2) finalize32(adlen, msglen uint64) [32]byte {
+	return s.finalize32Generic(adlen, msglen)
+}
+
+func (s *state128x2) finalizeMac16(dlen uint64) [16]byte {
+	return s.finalizeMac16Generic(dlen)
+}
+
+func (s *state128x2) finalizeMac32(dlen uint64) [32]byte {
+	return s.finalizeMac32Generic(dlen)
+}
@@ -0,0 +1,180 @@
+//go:build goexperiment.simd && amd64
+
+package aegis
+
+import (
+	"simd/archsimd"
+
+	"github.com/balasanjay/aegis/internal/impl"
+)
+
+func (s *state128x2) init(key, nonce *[16]byte) {
+	switch active {
+	case implVAES512, implVAES256:
+		state := impl.InitState128x2(archsimd.LoadUint8x16(key), archsimd.LoadUint8x16(nonce))
+		impl.StoreState128x2(&s.w, state)
+	case implAESNI:
+		state := impl.InitState128x2AESNI(archsimd.LoadUint8x16(key), archsimd.LoadUint8x16(nonce))
+		impl.StoreState128x2AESNI(&s.w, state)
+	default:
+		s.initGeneric(key, nonce)
+	}
+}
+
+func (s *state128x2) absorb(src []byte) {
+	switch active {
+	case implVAES512, implVAES256:
+		state := impl.LoadState128x2(&s.w)
+		for i := 0; i+64 <= len(src); i += 64 {
+			m0 := archsimd.LoadUint8x32Slice(src[i : i+32])
+			m1 := archsimd.LoadUint8x32Slice(src[i+32 : i+64])
+			state = impl.UpdateState128x2(state, m0, m1)
+		}
+		impl.StoreState128x2(&s.w, state)
+	case implAESNI:
+		state := impl.LoadState128x2AESNI(&s.w)
+		for i := 0; i+64 <= len(src); i += 64 {
+			m00 := archsimd.LoadUint8x16Slice(src[i : i+16])
+			m01 := archsimd.LoadUint8x16Slice(src[i+16 : i+32])
+			m10 := archsimd.LoadUint8x16Slice(src[i+32 : i+48])
+			m11 := archsimd.LoadUint8x16Slice(src[i+48 : i+64])
+			state = impl.UpdateState128x2AESNI(state, m00, m01, m10, m11)
+		}
+		impl.StoreState128x2AESNI(&s.w, state)
+	default:
+		s.absorbGeneric(src)
+	}
+}
+
+func (s *state128x2) enc(dst, src []byte) {
+	switch active {
+	case implVAES512, implVAES256:
+		state := impl.LoadState128x2(&s.w)
+		for i := 0; i+64 <= len(src); i += 64 {
+			p0 := archsimd.LoadUint8x32Slice(src[i : i+32])
+			p1 := archsimd.LoadUint8x32Slice(src[i+32 : i+64])
+
+			var c0, c1 archsimd.Uint8x32
+			state, c0, c1 = impl.Enc128x2(state, p0, p1)
+
+			c0.StoreSlice(dst[i : i+32])
+			c1.StoreSlice(dst[i+32 : i+64])
+		}
+		impl.StoreState128x2(&s.w, state)
+	case implAESNI:
+		state := impl.LoadState128x2AESNI(&s.w)
+		for i := 0; i+64 <= len(src); i += 64 {
+			p00 := archsimd.LoadUint8x16Slice(src[i : i+16])
+			p01 := archsimd.LoadUint8x16Slice(src[i+16 : i+32])
+			p10 := archsimd.LoadUint8x16Slice(src[i+32 : i+48])
+			p11 := archsimd.LoadUint8x16Slice(src[i+48 : i+64])
+
+			var c00, c01, c10, c11 archsimd.Uint8x16
+			state, c00, c01, c10, c11 = impl.Enc128x2AESNI(state, p00, p01, p10, p11)
+
+			c00.StoreSlice(dst[i : i+16])
+			c01.StoreSlice(dst[i+16 : i+32])
+			c10.StoreSlice(dst[i+32 : i+48])
+			c11.StoreSlice(dst[i+48 : i+64])
+		}
+		impl.StoreState128x2AESNI(&s.w, state)
+	default:
+		s.encGeneric(dst, src)
+	}
+}
+
+func (s *state128x2) dec(dst, src []byte) {
+	switch active {
+	case implVAES512, implVAES256:
+		state := impl.LoadState128x2(&s.w)
+		for i := 0; i+64 <= len(src); i += 64 {
+			c0 := archsimd.LoadUint8x32Slice(src[i : i+32])
+			c1 := archsimd.LoadUint8x32Slice(src[i+32 : i+64])
+
+			var p0, p1 archsimd.Uint8x32
+			state, p0, p1 = impl.Dec128x2(state, c0, c1)
+
+			p0.StoreSlice(dst[i : i+32])
+			p1.StoreSlice(dst[i+32 : i+64])
+		}
+		impl.StoreState128x2(&s.w, state)
+	case implAESNI:
+		state := impl.LoadState128x2AESNI(&s.w)
+		for i := 0; i+64 <= len(src); i += 64 {
+			c00 := archsimd.LoadUint8x16Slice(src[i : i+16])
+			c01 := archsimd.LoadUint8x16Slice(src[i+16 : i+32])
+			c10 := archsimd.LoadUint8x16Slice(src[i+32 : i+48])
+			c11 := archsimd.LoadUint8x16Slice(src[i+48 : i+64])
+
+			var p00, p01, p10, p11 archsimd.Uint8x16
+			state, p00, p01, p10, p11 = impl.Dec128x2AESNI(state, c00, c01, c10, c11)
+
+			p00.StoreSlice(dst[i : i+16])
+			p01.StoreSlice(dst[i+16 : i+32])
+			p10.StoreSlice(dst[i+32 : i+48])
+			p11.StoreSlice(dst[i+48 : i+64])
+		}
+		impl.StoreState128x2AESNI(&s.w, state)
+	default:
+		s.decGeneric(dst, src)
+	}
+}
+
+func (s *state128x2) decPartial(c [64]byte, clen int) [64]byte {
+	switch active {
+	case implVAES512, implVAES256:
+		state, p := impl.DecPartial128x2(impl.LoadState128x2(&s.w), c, clen)
+		impl.StoreState128x2(&s.w, state)
+		return p
+	case implAESNI:
+		state, p := impl.DecPartial128x2AESNI(impl.LoadState128x2AESNI(&s.w), c, clen)
+		impl.StoreState128x2AESNI(&s.w, state)
+		return p
+	default:
+		return s.decPartialGeneric(c, clen)
+	}
+}
+
+func (s *state128x2) finalize16(adlen, msglen uint64) [16]byte {
+	switch active {
+	case implVAES512, implVAES256:
+		return impl.Finalize128x2_16(impl.LoadState128x2(&s.w), adlen, msglen)
+	case implAESNI:
+		return impl.Finalize128x2AESNI_16(impl.LoadState128x2AESNI(&s.w), adlen, msglen)
+	default:
+		return s.finalize16Generic(adlen, msglen)
+	}
+}
+
+func (s *state128x2) finalize32(adlen, msglen uint64) [32]byte {
+	switch active {
+	case implVAES512, implVAES256:
+		return impl.Finalize128x2_32(impl.LoadState128x2(&s.w), adlen, msglen)
+	case implAESNI:
+		return impl.Finalize128x2AESNI_32(impl.LoadState128x2AESNI(&s.w), adlen, msglen)
+	default:
+		return s.finalize32Generic(adlen, msglen)
+	}
+}
+
+func (s *state128x2) finalizeMac16(dlen uint64) [16]byte {
+	switch active {
+	case implVAES512, implVAES256:
+		return impl.Finalize128x2Mac_16(impl.LoadState128x2(&s.w), dlen)
+	case implAESNI:
+		return impl.Finalize128x2MacAESNI_16(impl.LoadState128x2AESNI(&s.w), dlen)
+	default:
+		return s.finalizeMac16Generic(dlen)
+	}
+}
+
+func (s *state128x2) finalizeMac32(dlen uint64) [32]byte {
+	switch active {
+	case implVAES512, implVAES256:
+		return impl.Finalize128x2Mac_32(impl.LoadState128x2(&s.w), dlen)
+	case implAESNI:
+		return impl.Finalize128x2MacAESNI_32(impl.LoadState128x2AESNI(&s.w), dlen)
+	default:
+		return s.finalizeMac32Generic(dlen)
+	}
+}
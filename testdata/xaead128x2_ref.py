@@ -0,0 +1,220 @@
+#!/usr/bin/env python3
+"""Independent reference check of the XAEAD128x2 test vectors.
+
+This is a from-scratch implementation of AEGIS-128X2 and AEGIS-128X2-MAC,
+written from draft-irtf-cfrg-aegis-aead and sharing no code with the Go
+package: even the AES round is derived here, S-box included, from its
+definition over GF(2^8). It first checks itself against the AEGIS-128X2 test
+vectors published in the draft, then recomputes every vector in
+xaead128x2.json and reports any difference.
+
+Run it from anywhere with python3; it needs nothing beyond the standard
+library.
+"""
+
+import json
+import os
+import sys
+
+D = 2  # lanes
+C0 = bytes.fromhex("000101020305080d1522375990e97962")
+C1 = bytes.fromhex("db3d18556dc22ff12011314273b528dd")
+
+
+def gf_mul(a, b):
+    r = 0
+    while b:
+        if b & 1:
+            r ^= a
+        a = ((a << 1) ^ 0x11B) if a & 0x80 else a << 1
+        b >>= 1
+    return r
+
+
+def make_sbox():
+    sbox = []
+    for x in range(256):
+        inv = next((y for y in range(1, 256) if gf_mul(x, y) == 1), 0)
+        s = inv
+        for shift in range(1, 5):
+            s ^= ((inv << shift) | (inv >> (8 - shift))) & 0xFF
+        sbox.append(s ^ 0x63)
+    return sbox
+
+
+SBOX = make_sbox()
+
+
+def xor(*blocks):
+    out = bytearray(len(blocks[0]))
+    for b in blocks:
+        for i, v in enumerate(b):
+            out[i] ^= v
+    return bytes(out)
+
+
+def and_(a, b):
+    return bytes(x & y for x, y in zip(a, b))
+
+
+def aes_round(block, rk):
+    """One AES encryption round: SubBytes, ShiftRows, MixColumns, AddRoundKey.
+    Bytes are in the usual column-major order."""
+    s = [SBOX[v] for v in block]
+    s = [s[(4 * (c + r) + r) % 16] for c in range(4) for r in range(4)]
+    out = bytearray(16)
+    for c in range(4):
+        a = s[4 * c:4 * c + 4]
+        for r in range(4):
+            out[4 * c + r] = (gf_mul(a[r], 2) ^ gf_mul(a[(r + 1) % 4], 3)
+                              ^ a[(r + 2) % 4] ^ a[(r + 3) % 4])
+    return xor(out, rk)
+
+
+def lanes(v):
+    return [v[16 * i:16 * i + 16] for i in range(D)]
+
+
+def vround(a, b):
+    return b"".join(aes_round(x, y) for x, y in zip(lanes(a), lanes(b)))
+
+
+class Aegis128X2:
+    def __init__(self, key, nonce):
+        k, n = key * D, nonce * D
+        self.v = [xor(k, n), C1 * D, C0 * D, C1 * D,
+                  xor(k, n), xor(k, C0 * D), xor(k, C1 * D), xor(k, C0 * D)]
+        ctx = b"".join(bytes([i, D - 1]) + bytes(14) for i in range(D))
+        for _ in range(10):
+            self.v[3] = xor(self.v[3], ctx)
+            self.v[7] = xor(self.v[7], ctx)
+            self.update(n, k)
+
+    def update(self, m0, m1):
+        v = self.v
+        self.v = [vround(v[7], xor(v[0], m0)), vround(v[0], v[1]),
+                  vround(v[1], v[2]), vround(v[2], v[3]),
+                  vround(v[3], xor(v[4], m1)), vround(v[4], v[5]),
+                  vround(v[5], v[6]), vround(v[6], v[7])]
+
+    def absorb(self, block):
+        self.update(block[:16 * D], block[16 * D:])
+
+    def keystream(self):
+        v = self.v
+        return (xor(v[6], v[1], and_(v[2], v[3])) +
+                xor(v[2], v[5], and_(v[6], v[7])))
+
+    def absorb_all(self, data):
+        rate = 32 * D
+        for i in range(0, len(data), rate):
+            self.absorb(data[i:i + rate].ljust(rate, b"\0"))
+
+    def encrypt(self, msg):
+        rate, out = 32 * D, b""
+        for i in range(0, len(msg), rate):
+            chunk = msg[i:i + rate]
+            padded = chunk.ljust(rate, b"\0")
+            out += xor(padded, self.keystream())[:len(chunk)]
+            self.absorb(padded)
+        return out
+
+    def lane_tag(self, i, tag_len):
+        v = [lanes(x)[i] for x in self.v]
+        if tag_len == 16:
+            return xor(*v[:7])
+        return xor(*v[:4]) + xor(*v[4:])
+
+    def final_rounds(self, a, b):
+        u = a.to_bytes(8, "little") + b.to_bytes(8, "little")
+        t = b"".join(xor(x, u) for x in lanes(self.v[2]))
+        for _ in range(7):
+            self.update(t, t)
+
+    def finalize(self, ad_len, msg_len, tag_len):
+        self.final_rounds(8 * ad_len, 8 * msg_len)
+        tag = bytes(tag_len)
+        for i in range(D):
+            tag = xor(tag, self.lane_tag(i, tag_len))
+        return tag
+
+    def finalize_mac(self, data_len, tag_len):
+        self.final_rounds(8 * data_len, 8 * tag_len)
+        if tag_len == 16:
+            tags = b"".join(self.lane_tag(i, 16) for i in range(D))
+        else:
+            tags = b"".join(self.lane_tag(i, 32) for i in range(1, D))
+        # Each 256-bit chunk of the tags is absorbed into the first lane only.
+        for i in range(0, len(tags), 32):
+            v = tags[i:i + 32]
+            self.update(v[:16].ljust(16 * D, b"\0"), v[16:].ljust(16 * D, b"\0"))
+        u = D.to_bytes(8, "little") + (8 * tag_len).to_bytes(8, "little")
+        t = xor(lanes(self.v[2])[0], u).ljust(16 * D, b"\0")
+        for _ in range(7):
+            self.update(t, t)
+        return self.lane_tag(0, tag_len)
+
+
+def seal(key, nonce, msg, ad, tag_len=16):
+    s = Aegis128X2(key, nonce)
+    s.absorb_all(ad)
+    ct = s.encrypt(msg)
+    return ct, s.finalize(len(ad), len(msg), tag_len)
+
+
+def mac(key, nonce, data, tag_len=16):
+    s = Aegis128X2(key, nonce)
+    s.absorb_all(data)
+    return s.finalize_mac(len(data), tag_len)
+
+
+def check(name, got, want):
+    if got != want:
+        sys.exit(f"{name}: got {got.hex()}, want {want.hex()}")
+
+
+def self_test():
+    """The AEGIS-128X2 and AEGIS-128X2-MAC test vectors of the draft."""
+    h = bytes.fromhex
+    key, nonce = h("000102030405060708090a0b0c0d0e0f"), h("101112131415161718191a1b1c1d1e1f")
+
+    ct, tag = seal(key, nonce, b"", b"")
+    check("AEGIS-128X2 vector 1 tag128", tag, h("63117dc57756e402819a82e13eca8379"))
+    ct, tag = seal(key, nonce, b"", b"", 32)
+    check("AEGIS-128X2 vector 1 tag256", tag,
+          h("b92c71fdbd358b8a4de70b27631ace90cffd9b9cfba82028412bac41b4f53759"))
+
+    msg, ad = h("04050607") * 30, h("0102030401020304")
+    ct, tag = seal(key, nonce, msg, ad)
+    check("AEGIS-128X2 vector 2 ciphertext", ct, h(
+        "5795544301997f93621b278809d6331b3bfa6f18e90db12c4aa35965b5e98c5f"
+        "c6fb4e54bcb6111842c20637252eff747cb3a8f85b37de80919a589fe0f24872"
+        "bc926360696739e05520647e390989e1eb5fd42f99678a0276a498f8c454761c"
+        "9d6aacb647ad56be62b29c22cd4b5761b38f43d5a5ee062f"))
+    check("AEGIS-128X2 vector 2 tag128", tag, h("1aebc200804f405cab637f2adebb6d77"))
+
+    key, nonce = h("10010000000000000000000000000000"), h("10000200000000000000000000000000")
+    data = bytes(range(0x23))
+    check("AEGIS-128X2-MAC tag128", mac(key, nonce, data),
+          h("6873ee34e6b5c59143b6d35c5e4f2c6e"))
+    check("AEGIS-128X2-MAC tag256", mac(key, nonce, data, 32),
+          h("afcba3fc2d63c8d6c7f2d63f3ec8fbbbaf022e15ac120e78ffa7755abccd959c"))
+
+
+def main():
+    self_test()
+    path = os.path.join(os.path.dirname(os.path.abspath(__file__)), "xaead128x2.json")
+    with open(path) as f:
+        vectors = json.load(f)["vectors"]
+    for i, v in enumerate(vectors):
+        key, nonce = bytes.fromhex(v["key"]), bytes.fromhex(v["nonce"])
+        subkey = mac(key, nonce[:16], b"XAEAD128x2")
+        check(f"vector {i} subkey", subkey, bytes.fromhex(v["subkey"]))
+        ct, tag = seal(subkey, nonce[16:], bytes.fromhex(v["plaintext"]), bytes.fromhex(v["ad"]))
+        check(f"vector {i} ciphertext", ct, bytes.fromhex(v["ciphertext"]))
+        check(f"vector {i} tag", tag, bytes.fromhex(v["tag"]))
+    print(f"all {len(vectors)} vectors in {os.path.basename(path)} match")
+
+
+if __name__ == "__main__":
+    main()